@@ -0,0 +1,201 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// pullRequestNumberRxp matches the pull request number in squash merge "Title (#123)" and
+// merge commit "Merge pull request #123 from ..." messages.
+var pullRequestNumberRxp = regexp.MustCompile(`\(#([0-9]+)\)|^Merge pull request #([0-9]+)`)
+
+type changelogCategory struct {
+	Title  string
+	Labels []string
+}
+
+// changelogCategories are matched in order against the lowercase label names of a pull
+// request; pull requests not matching any go to changelogOtherChanges.
+var changelogCategories = []changelogCategory{
+	{Title: "Breaking Changes", Labels: []string{"breaking"}},
+	{Title: "Features", Labels: []string{"feature", "enhancement"}},
+	{Title: "Bug Fixes", Labels: []string{"bug"}},
+}
+
+const changelogOtherChanges = "Other Changes"
+
+// getMergedPullRequests returns the merged pull requests whose commits are between the from
+// and to refs of the repository, sorted by number.
+// truncated is true if GitHub didn't return all the commits of the comparison.
+func getMergedPullRequests(ctx context.Context, client *GithubClient, owner, repo, from, to string) (pullRequests []*github.PullRequest, truncated bool, err error) {
+	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, from, to)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to compare %s...%s in %s/%s", from, to, owner, repo)
+	}
+
+	seen := make(map[int]bool)
+	for _, commit := range comparison.Commits {
+		number, ok := getPullRequestNumber(commit.GetCommit().GetMessage())
+		if !ok || seen[number] {
+			continue
+		}
+		seen[number] = true
+
+		pullRequest, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			var gerr *github.ErrorResponse
+			if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
+				LogInfo("pull request #%d of %s/%s was not found, skipping it", number, owner, repo)
+				continue
+			}
+			return nil, false, errors.Wrapf(err, "failed to get pull request #%d of %s/%s", number, owner, repo)
+		}
+
+		if !pullRequest.GetMerged() && pullRequest.MergedAt == nil {
+			continue
+		}
+
+		pullRequests = append(pullRequests, pullRequest)
+	}
+
+	sort.Slice(pullRequests, func(i, j int) bool {
+		return pullRequests[i].GetNumber() < pullRequests[j].GetNumber()
+	})
+
+	return pullRequests, comparison.GetTotalCommits() > len(comparison.Commits), nil
+}
+
+// getPullRequestNumber extracts the pull request number from the first line of a commit message.
+func getPullRequestNumber(message string) (int, bool) {
+	firstLine := strings.SplitN(message, "\n", 2)[0]
+
+	matches := pullRequestNumberRxp.FindAllStringSubmatch(firstLine, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+
+	// Use the last reference, as titles may mention other pull requests before the squash suffix.
+	match := matches[len(matches)-1]
+	numberStr := match[1]
+	if numberStr == "" {
+		numberStr = match[2]
+	}
+
+	number, err := strconv.Atoi(numberStr)
+	if err != nil {
+		return 0, false
+	}
+
+	return number, true
+}
+
+// getChangelogCategory returns the title of the changelog section of a pull request.
+func getChangelogCategory(pullRequest *github.PullRequest) string {
+	for _, category := range changelogCategories {
+		for _, label := range pullRequest.Labels {
+			name := strings.ToLower(label.GetName())
+			for _, categoryLabel := range category.Labels {
+				if strings.Contains(name, categoryLabel) {
+					return category.Title
+				}
+			}
+		}
+	}
+
+	return changelogOtherChanges
+}
+
+// formatChangelog returns the Markdown changelog of the pull requests grouped by category.
+func formatChangelog(pullRequests []*github.PullRequest, truncated bool) string {
+	sections := make(map[string][]*github.PullRequest)
+	for _, pullRequest := range pullRequests {
+		category := getChangelogCategory(pullRequest)
+		sections[category] = append(sections[category], pullRequest)
+	}
+
+	titles := []string{}
+	for _, category := range changelogCategories {
+		titles = append(titles, category.Title)
+	}
+	titles = append(titles, changelogOtherChanges)
+
+	msg := ""
+	for _, title := range titles {
+		if len(sections[title]) == 0 {
+			continue
+		}
+
+		msg += fmt.Sprintf("### %s\n", title)
+		for _, pullRequest := range sections[title] {
+			msg += fmt.Sprintf("- %s ([#%d](%s)) @%s\n", pullRequest.GetTitle(), pullRequest.GetNumber(), pullRequest.GetHTMLURL(), pullRequest.GetUser().GetLogin())
+		}
+		msg += "\n"
+	}
+
+	if msg == "" {
+		msg = "No merged pull requests found.\n"
+	}
+
+	if truncated {
+		msg += "_GitHub didn't return all the commits of the comparison, this changelog is incomplete._\n"
+	}
+
+	return msg
+}
+
+// publishChangelogDraft creates a draft release for the tag with the changelog as body,
+// or updates the body of the existing draft release. Published releases are left untouched.
+func publishChangelogDraft(ctx context.Context, client *GithubClient, owner, repo, tag, body string) (*github.RepositoryRelease, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list releases of %s/%s", owner, repo)
+		}
+
+		for _, release := range releases {
+			if release.GetTagName() != tag {
+				continue
+			}
+
+			if !release.GetDraft() {
+				return nil, errors.Errorf("release %s of %s/%s is already published", tag, owner, repo)
+			}
+
+			release, _, err = client.Repositories.EditRelease(ctx, owner, repo, release.GetID(), &github.RepositoryRelease{Body: github.String(body)})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to update the draft release %s of %s/%s", tag, owner, repo)
+			}
+
+			return release, nil
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	release, _, err := client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+		TagName: github.String(tag),
+		Name:    github.String(tag),
+		Body:    github.String(body),
+		Draft:   github.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the draft release %s of %s/%s", tag, owner, repo)
+	}
+
+	return release, nil
+}
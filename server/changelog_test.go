@@ -0,0 +1,172 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestGetPullRequestNumber(t *testing.T) {
+	testCases := []struct {
+		message string
+		number  int
+		found   bool
+	}{
+		{"Fix the build (#123)", 123, true},
+		{"Merge pull request #456 from mattermost/fix\n\nFix the build", 456, true},
+		{"Revert \"Add feature (#12)\" (#34)", 34, true},
+		{"Fix the build\n\nFollow up of (#123)", 0, false},
+		{"Bump version", 0, false},
+	}
+
+	for _, tc := range testCases {
+		number, found := getPullRequestNumber(tc.message)
+		require.Equal(t, tc.found, found, tc.message)
+		require.Equal(t, tc.number, number, tc.message)
+	}
+}
+
+func newTestPullRequest(number int, title string, labels ...string) *github.PullRequest {
+	pullRequest := &github.PullRequest{
+		Number:   github.Int(number),
+		Title:    github.String(title),
+		HTMLURL:  github.String("https://github.com/mattermost/mattermost-server/pull/" + title),
+		Merged:   github.Bool(true),
+		MergedAt: &time.Time{},
+		User:     &github.User{Login: github.String("dev")},
+	}
+	for _, label := range labels {
+		pullRequest.Labels = append(pullRequest.Labels, &github.Label{Name: github.String(label)})
+	}
+
+	return pullRequest
+}
+
+func TestGetMergedPullRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+	pullsMock := mocks.NewMockGithubPullRequestsService(ctrl)
+	client := &GithubClient{Repositories: repoMock, PullRequests: pullsMock}
+
+	commit := func(message string) github.RepositoryCommit {
+		return github.RepositoryCommit{Commit: &github.Commit{Message: github.String(message)}}
+	}
+
+	repoMock.EXPECT().CompareCommits(gomock.Eq(ctx), "mattermost", "mattermost-server", "v5.36.0", "v5.37.0").Return(&github.CommitsComparison{
+		TotalCommits: github.Int(5),
+		Commits: []github.RepositoryCommit{
+			commit("Fix crash (#3)"),
+			commit("Add feature (#1)"),
+			commit("Bump version"),
+			commit("Cherry pick of fix crash (#3)"),
+			commit("Unmerged (#2)"),
+		},
+	}, nil, nil)
+
+	unmerged := newTestPullRequest(2, "Unmerged")
+	unmerged.Merged = github.Bool(false)
+	unmerged.MergedAt = nil
+
+	pullsMock.EXPECT().Get(gomock.Eq(ctx), "mattermost", "mattermost-server", 3).Return(newTestPullRequest(3, "Fix crash", "Type/Bug"), nil, nil)
+	pullsMock.EXPECT().Get(gomock.Eq(ctx), "mattermost", "mattermost-server", 1).Return(newTestPullRequest(1, "Add feature", "Type/Enhancement"), nil, nil)
+	pullsMock.EXPECT().Get(gomock.Eq(ctx), "mattermost", "mattermost-server", 2).Return(unmerged, nil, nil)
+
+	pullRequests, truncated, err := getMergedPullRequests(ctx, client, "mattermost", "mattermost-server", "v5.36.0", "v5.37.0")
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, pullRequests, 2)
+	require.Equal(t, 1, pullRequests[0].GetNumber())
+	require.Equal(t, 3, pullRequests[1].GetNumber())
+}
+
+func TestFormatChangelog(t *testing.T) {
+	t.Run("groups pull requests by label", func(t *testing.T) {
+		pullRequests := []*github.PullRequest{
+			newTestPullRequest(1, "a", "Type/Bug"),
+			newTestPullRequest(2, "b", "Type/Enhancement"),
+			newTestPullRequest(3, "c", "Type/Bug", "Breaking Change"),
+			newTestPullRequest(4, "d", "Docs"),
+			newTestPullRequest(5, "e"),
+		}
+
+		expected := "### Breaking Changes\n" +
+			"- c ([#3](https://github.com/mattermost/mattermost-server/pull/c)) @dev\n\n" +
+			"### Features\n" +
+			"- b ([#2](https://github.com/mattermost/mattermost-server/pull/b)) @dev\n\n" +
+			"### Bug Fixes\n" +
+			"- a ([#1](https://github.com/mattermost/mattermost-server/pull/a)) @dev\n\n" +
+			"### Other Changes\n" +
+			"- d ([#4](https://github.com/mattermost/mattermost-server/pull/d)) @dev\n" +
+			"- e ([#5](https://github.com/mattermost/mattermost-server/pull/e)) @dev\n\n"
+		require.Equal(t, expected, formatChangelog(pullRequests, false))
+	})
+
+	t.Run("empty and truncated", func(t *testing.T) {
+		require.Equal(t, "No merged pull requests found.\n_GitHub didn't return all the commits of the comparison, this changelog is incomplete._\n", formatChangelog(nil, true))
+	})
+}
+
+func TestPublishChangelogDraft(t *testing.T) {
+	t.Run("creates the draft release", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().ListReleases(gomock.Eq(ctx), "owner", "repo", gomock.Any()).Return([]*github.RepositoryRelease{
+			{ID: github.Int64(1), TagName: github.String("v1.0.0")},
+		}, &github.Response{}, nil)
+		repoMock.EXPECT().CreateRelease(gomock.Eq(ctx), "owner", "repo", &github.RepositoryRelease{
+			TagName: github.String("v1.1.0"),
+			Name:    github.String("v1.1.0"),
+			Body:    github.String("body"),
+			Draft:   github.Bool(true),
+		}).Return(&github.RepositoryRelease{ID: github.Int64(2)}, nil, nil)
+
+		release, err := publishChangelogDraft(ctx, client, "owner", "repo", "v1.1.0", "body")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), release.GetID())
+	})
+
+	t.Run("updates the existing draft release", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().ListReleases(gomock.Eq(ctx), "owner", "repo", gomock.Any()).Return([]*github.RepositoryRelease{
+			{ID: github.Int64(2), TagName: github.String("v1.1.0"), Draft: github.Bool(true)},
+		}, &github.Response{}, nil)
+		repoMock.EXPECT().EditRelease(gomock.Eq(ctx), "owner", "repo", int64(2), &github.RepositoryRelease{Body: github.String("body")}).Return(&github.RepositoryRelease{ID: github.Int64(2)}, nil, nil)
+
+		release, err := publishChangelogDraft(ctx, client, "owner", "repo", "v1.1.0", "body")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), release.GetID())
+	})
+
+	t.Run("doesn't touch published releases", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().ListReleases(gomock.Eq(ctx), "owner", "repo", gomock.Any()).Return([]*github.RepositoryRelease{
+			{ID: github.Int64(2), TagName: github.String("v1.1.0"), Draft: github.Bool(false)},
+		}, &github.Response{}, nil)
+
+		release, err := publishChangelogDraft(ctx, client, "owner", "repo", "v1.1.0", "body")
+		require.EqualError(t, err, "release v1.1.0 of owner/repo is already published")
+		require.Nil(t, release)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type MatterbuildConfig struct {
@@ -20,16 +21,55 @@ type MatterbuildConfig struct {
 	AllowedUsers  []string
 	ReleaseUsers  []string
 
-	PluginSigningSSHPublicCertPath string // Used for local development
-	PluginSigningSSHKeyPath        string
-	PluginSigningSSHUser           string
-	PluginSigningSSHHost           string
-	PluginSigningSSHHostPublicKey  string
+	PluginSigningBackend           string // ssh (default), gpg or local
+	PluginSigningPublicKeyPath     string // Armored public key to verify signatures, defaults to the production key
+	PluginVerificationKeys         []*PluginVerificationKey
+	PluginSigningGPGHomeDir        string
+	PluginSigningGPGKeyID          string
+	PluginSigningGPGPassphrasePath string
+
+	PluginSigningPrivateKeyPath           string // Armored private key used by the local backend
+	PluginSigningPrivateKeyPassphrasePath string
+
+	PluginSigningSSHPublicCertPath    string // Used for local development
+	PluginSigningSSHKeyPath           string
+	PluginSigningSSHUser              string
+	PluginSigningSSHHost              string
+	PluginSigningSSHHostPublicKey     string
+	PluginSigningSSHPort              int    // Defaults to 22
+	PluginSigningSSHJumpHost          string // Optional host[:port] the signing server is reached through
+	PluginSigningSSHJumpHostPublicKey string
+	PluginSigningSSHParallelism       int // Files signed concurrently, defaults to 4
 
 	PluginSigningAWSAccessKey      string
 	PluginSigningAWSSecretKey      string
 	PluginSigningAWSRegion         string
 	PluginSigningAWSS3PluginBucket string
+	PluginSigningAWSS3Endpoint     string // Overrides the AWS S3 endpoint
+
+	PluginOperationsDir       string // Work directories of plugin cuts, kept to resume failed ones. Defaults to a temp dir
+	PluginAssetTimeoutMinutes int    // How long to wait for the plugin release asset, defaults to 50
+
+	PluginRepositories       []string // Patterns of the repositories of GithubOrg plugins may be released from, all by default
+	PluginRepositoriesDenied []string // Patterns of the repositories plugins may not be released from
+	PluginRepositoryTopics   []string // Topics the plugin repositories must have, e.g. mattermost-plugin
+
+	PluginTagTaggerName      string   // Tagger of the plugin tags, none by default
+	PluginTagTaggerEmail     string   // Email of the tagger of the plugin tags
+	PluginTagMessageTemplate string   // text/template of the plugin tag messages, given .Repository, .Tag, .Username and .Command. Defaults to the tag
+	PluginTagRequireChecks   bool     // Only tag commits whose statuses and check runs succeeded
+	PluginTagRequireBranch   bool     // Only tag commits of the default branch or of a release branch
+	PluginTagReleaseBranches []string // Patterns of the release branches, defaults to release-*
+
+	PluginBundles          map[string][]string // Named sets of repo@tag plugin releases, cut together by cutplugins
+	PluginBatchParallelism int                 // Plugin releases cutplugins cuts concurrently, defaults to 3
+
+	PluginAutoReleaseRepositories []string // Repositories of GithubOrg whose published releases are cut automatically, through the release webhooks
+	PluginAutoReleaseWebhookURL   string   // Mattermost incoming webhook the automatic plugin releases are reported to
+
+	PluginDownloadBaseURL string // Public URL of the S3 release bucket, defaults to https://plugins.releases.mattermost.com/release
+	MarketplaceRepository string // owner/name, defaults to mattermost/mattermost-marketplace
+	MarketplaceBaseBranch string // Defaults to production
 
 	CIServerJenkinsUserName string
 	CIServerJenkinsToken    string
@@ -44,8 +84,17 @@ type MatterbuildConfig struct {
 	GithubAccessToken         string
 	GithubUsername            string
 	GithubOrg                 string
+	GithubBaseURL             string // Optional, defaults to the public github.com API
+	GithubUploadURL           string // Optional, defaults to the /api/uploads/ URL next to GithubBaseURL
+	GithubAppID               int64  // Authenticate as this GitHub App instead of with GithubAccessToken
+	GithubAppPrivateKeyPath   string // PEM private key of the GitHub App
+	GithubAppInstallationID   int64  // Optional, defaults to the installation of the GitHub App in GithubOrg
+	GithubWebhookSecret       string // Secret of the release webhooks of the plugin repositories, sent to /github_webhook
 	Repositories              []*Repository
 
+	CutPreflightChecks  []string // Checks run before cutting a release: branch, ci, blockers, translations
+	ReleaseBlockerLabel string   // Defaults to "Release Blocker"
+
 	KubeDeployJob string
 
 	PipelineTriggers map[string]*PipelineTrigger
@@ -56,6 +105,27 @@ type Repository struct {
 	Name  string
 }
 
+// PluginVerificationKey is an armored public key plugin signatures are verified against,
+// accepted within an optional validity window to allow rotating the signing key.
+type PluginVerificationKey struct {
+	Path      string
+	NotBefore time.Time // Optional, RFC 3339
+	NotAfter  time.Time // Optional, RFC 3339
+}
+
+// IsValidAt reports whether t falls within the validity window of the key.
+func (k *PluginVerificationKey) IsValidAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+
+	if !k.NotAfter.IsZero() && t.After(k.NotAfter) {
+		return false
+	}
+
+	return true
+}
+
 type PipelineTrigger struct {
 	Description string
 	URL         string
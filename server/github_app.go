@@ -0,0 +1,261 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// githubAppJWTLifetime is how long the JWTs authenticating as the GitHub App are valid, GitHub
+	// accepting at most 10 minutes.
+	githubAppJWTLifetime = 9 * time.Minute
+
+	// githubAppJWTClockSkew backdates the JWTs, in case the clock of GitHub is behind.
+	githubAppJWTClockSkew = time.Minute
+)
+
+// githubApps caches the GitHub App installations by configuration, for their installation tokens
+// to be reused until they expire.
+var githubApps sync.Map
+
+// githubAppJWT returns a JWT authenticating as the GitHub App, signed with its private key.
+func githubAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal jwt header")
+	}
+
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-githubAppJWTClockSkew).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal jwt claims")
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign jwt")
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// loadGithubAppPrivateKey reads the PEM encoded private key of the GitHub App, in the PKCS #1
+// format GitHub generates, or PKCS #8.
+func loadGithubAppPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read github app private key")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found in %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse github app private key")
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+
+	return key, nil
+}
+
+// githubAppTransport authenticates the requests as the GitHub App itself.
+type githubAppTransport struct {
+	appID int64
+	key   *rsa.PrivateKey
+	base  http.RoundTripper
+}
+
+func (t *githubAppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := githubAppJWT(t.appID, t.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// githubApp creates the installation tokens of the GitHub App, scoped to a single repository or
+// to the whole installation. The installation is the one of GithubOrg unless
+// GithubAppInstallationID is set.
+type githubApp struct {
+	cfg    *MatterbuildConfig
+	client *http.Client // Authenticated as the GitHub App
+
+	mut            sync.Mutex
+	installationID int64
+
+	tokenSources sync.Map // Token sources by lowercase owner/name, "" for the whole installation
+}
+
+// getInstallationID returns the ID of the installation, looking it up the first time if not
+// configured.
+func (a *githubApp) getInstallationID(ctx context.Context, client *github.Client) (int64, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if a.installationID == 0 {
+		installation, _, err := client.Apps.FindOrganizationInstallation(ctx, a.cfg.GithubOrg)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to find the github app installation of %s", a.cfg.GithubOrg)
+		}
+		a.installationID = installation.GetID()
+	}
+
+	return a.installationID, nil
+}
+
+// tokenSource returns the cached token source of the given repository, given as owner/name, or
+// of the whole installation if empty.
+func (a *githubApp) tokenSource(repository string) oauth2.TokenSource {
+	key := strings.ToLower(repository)
+	if ts, ok := a.tokenSources.Load(key); ok {
+		return ts.(oauth2.TokenSource)
+	}
+
+	ts, _ := a.tokenSources.LoadOrStore(key, oauth2.ReuseTokenSource(nil, &githubAppTokenSource{app: a, repository: repository}))
+	return ts.(oauth2.TokenSource)
+}
+
+// githubAppTokenSource creates the installation tokens of the GitHub App for a repository, or for
+// the whole installation if repository is empty.
+type githubAppTokenSource struct {
+	app        *githubApp
+	repository string // owner/name
+}
+
+// githubInstallationTokenRequest is the body of the installation token requests.
+type githubInstallationTokenRequest struct {
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+func (s *githubAppTokenSource) Token() (*oauth2.Token, error) {
+	client, err := newGithubAPIClient(s.app.client, s.app.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Token is called once the previous token expired, never concurrently for a repository
+	ctx := context.Background()
+	installationID, err := s.app.getInstallationID(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &githubInstallationTokenRequest{}
+	if s.repository != "" {
+		body.Repositories = []string{path.Base(s.repository)}
+	}
+
+	// The go-github release in use still creates installation tokens with the retired
+	// installations/:id/access_tokens endpoint
+	req, err := client.NewRequest(http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", installationID), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create installation token request")
+	}
+
+	token := new(github.InstallationToken)
+	if _, err = client.Do(ctx, req, token); err != nil {
+		return nil, errors.Wrapf(err, "failed to create a token for github app installation %d", installationID)
+	}
+
+	scope := s.repository
+	if scope == "" {
+		scope = "all repositories"
+	}
+	LogInfo("Created a token of github app installation %d for %s, expiring at %s", installationID, scope, token.GetExpiresAt())
+
+	return &oauth2.Token{AccessToken: token.GetToken(), Expiry: token.GetExpiresAt()}, nil
+}
+
+// githubAppInstallationTransport authenticates the requests with an installation token scoped to
+// the repository they target, or to the whole installation for the other endpoints.
+type githubAppInstallationTransport struct {
+	app  *githubApp
+	base http.RoundTripper
+}
+
+var githubRepositoryPathRxp = regexp.MustCompile(`/repos/([^/]+)/([^/]+)`)
+
+func (t *githubAppInstallationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	repository := ""
+	if matches := githubRepositoryPathRxp.FindStringSubmatch(req.URL.Path); matches != nil {
+		repository = matches[1] + "/" + matches[2]
+	}
+
+	token, err := t.app.tokenSource(repository).Token()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	token.SetAuthHeader(req)
+	return t.base.RoundTrip(req)
+}
+
+// newGithubHTTPClient returns an HTTP client authenticated with installation tokens of the GitHub
+// App if GithubAppID is set, with GithubAccessToken otherwise.
+func newGithubHTTPClient(ctx context.Context, cfg *MatterbuildConfig) (*http.Client, error) {
+	if cfg.GithubAppID == 0 {
+		return oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: cfg.GithubAccessToken})), nil
+	}
+
+	key := fmt.Sprintf("%s|%s|%d|%d|%s", cfg.GithubBaseURL, cfg.GithubOrg, cfg.GithubAppID, cfg.GithubAppInstallationID, cfg.GithubAppPrivateKeyPath)
+	app, ok := githubApps.Load(key)
+	if !ok {
+		privateKey, err := loadGithubAppPrivateKey(cfg.GithubAppPrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		app, _ = githubApps.LoadOrStore(key, &githubApp{
+			cfg: cfg,
+			client: &http.Client{
+				Transport: &githubAppTransport{appID: cfg.GithubAppID, key: privateKey, base: http.DefaultTransport},
+			},
+			installationID: cfg.GithubAppInstallationID,
+		})
+	}
+
+	return &http.Client{
+		Transport: &githubAppInstallationTransport{app: app.(*githubApp), base: http.DefaultTransport},
+	}, nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGithubAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	token, err := githubAppJWT(42, key, now)
+	require.NoError(t, err)
+
+	claims := verifyTestGithubAppJWT(t, &key.PublicKey, token)
+	require.Equal(t, map[string]int64{"iat": 1699999940, "exp": 1700000540, "iss": 42}, claims)
+}
+
+func TestLoadGithubAppPrivateKey(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs1Path := filepath.Join(dir, "pkcs1.pem")
+	require.NoError(t, os.WriteFile(pkcs1Path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	loaded, err := loadGithubAppPrivateKey(pkcs1Path)
+	require.NoError(t, err)
+	require.True(t, key.Equal(loaded))
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pkcs8Path := filepath.Join(dir, "pkcs8.pem")
+	require.NoError(t, os.WriteFile(pkcs8Path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), 0600))
+	loaded, err = loadGithubAppPrivateKey(pkcs8Path)
+	require.NoError(t, err)
+	require.True(t, key.Equal(loaded))
+
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a key"), 0600))
+	_, err = loadGithubAppPrivateKey(invalidPath)
+	require.EqualError(t, err, "no PEM data found in "+invalidPath)
+}
+
+func TestNewGithubClientAsGithubApp(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+
+	var tokensCreated int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/orgs/mattermost/installation":
+			claims := verifyTestGithubAppJWT(t, &key.PublicKey, strings.TrimPrefix(authorization, "Bearer "))
+			require.Equal(t, int64(42), claims["iss"])
+			w.Write([]byte(`{"id": 7}`))
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/app/installations/"):
+			verifyTestGithubAppJWT(t, &key.PublicKey, strings.TrimPrefix(authorization, "Bearer "))
+			var body githubInstallationTokenRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			scope := "all"
+			if len(body.Repositories) > 0 {
+				scope = strings.Join(body.Repositories, ",")
+			}
+
+			n := atomic.AddInt32(&tokensCreated, 1)
+			installationID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/app/installations/"), "/access_tokens")
+			// Tokens of installation 8 expire right away
+			tokenLifetime := time.Hour
+			if installationID == "8" {
+				tokenLifetime = 0
+			}
+			fmt.Fprintf(w, `{"token": "ghs_%s_%d_%s", "expires_at": %q}`, installationID, n, scope, time.Now().Add(tokenLifetime).Format(time.RFC3339))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/mattermost/"):
+			w.Header().Set("X-Token", strings.TrimPrefix(authorization, "Bearer "))
+			fmt.Fprintf(w, `{"name": %q}`, strings.TrimPrefix(r.URL.Path, "/repos/mattermost/"))
+		case r.Method == http.MethodGet && r.URL.Path == "/search/issues":
+			w.Header().Set("X-Token", strings.TrimPrefix(authorization, "Bearer "))
+			w.Write([]byte(`{"total_count": 0, "items": []}`))
+		default:
+			t.Errorf("unexpected GitHub request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &MatterbuildConfig{
+		GithubOrg:               "mattermost",
+		GithubBaseURL:           server.URL,
+		GithubAppID:             42,
+		GithubAppPrivateKeyPath: keyPath,
+	}
+
+	getRepositoryToken := func(cfg *MatterbuildConfig, repoName string) string {
+		client, err := NewGithubClient(ctx, cfg)
+		require.NoError(t, err)
+
+		repo, resp, err := client.Repositories.Get(ctx, "mattermost", repoName)
+		require.NoError(t, err)
+		require.Equal(t, repoName, repo.GetName())
+		return resp.Header.Get("X-Token")
+	}
+
+	t.Run("installation of the org, token per repository reused until it expires", func(t *testing.T) {
+		require.Equal(t, "ghs_7_1_mattermost-plugin-demo", getRepositoryToken(cfg, "mattermost-plugin-demo"))
+		require.Equal(t, "ghs_7_1_mattermost-plugin-demo", getRepositoryToken(cfg, "mattermost-plugin-demo"))
+		require.Equal(t, "ghs_7_2_mattermost-plugin-jira", getRepositoryToken(cfg, "mattermost-plugin-jira"))
+		require.EqualValues(t, 2, atomic.LoadInt32(&tokensCreated))
+	})
+
+	t.Run("token of the whole installation for the other endpoints", func(t *testing.T) {
+		client, err := NewGithubClient(ctx, cfg)
+		require.NoError(t, err)
+
+		_, resp, err := client.Search.Issues(ctx, "repo:mattermost/mattermost-plugin-demo is:pr", nil)
+		require.NoError(t, err)
+		require.Equal(t, "ghs_7_3_all", resp.Header.Get("X-Token"))
+	})
+
+	t.Run("configured installation, token refreshed once expired", func(t *testing.T) {
+		installationCfg := *cfg
+		installationCfg.GithubAppInstallationID = 8
+
+		require.Equal(t, "ghs_8_4_mattermost-plugin-demo", getRepositoryToken(&installationCfg, "mattermost-plugin-demo"))
+		require.Equal(t, "ghs_8_5_mattermost-plugin-demo", getRepositoryToken(&installationCfg, "mattermost-plugin-demo"))
+	})
+
+	t.Run("access token", func(t *testing.T) {
+		require.Equal(t, "token", getRepositoryToken(&MatterbuildConfig{GithubBaseURL: server.URL, GithubAccessToken: "token"}, "mattermost-plugin-demo"))
+	})
+}
+
+// verifyTestGithubAppJWT checks the signature of the JWT and returns its claims.
+func verifyTestGithubAppJWT(t *testing.T, key *rsa.PublicKey, token string) map[string]int64 {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"alg": "RS256", "typ": "JWT"}`, string(header))
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]int64
+	require.NoError(t, json.Unmarshal(payload, &claims))
+
+	return claims
+}
@@ -6,18 +6,28 @@ package server
 import (
 	"context"
 	"io"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/google/go-github/github"
-	"golang.org/x/oauth2"
+	"github.com/pkg/errors"
 )
 
 type GithubRepositoriesService interface {
 	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 	GetCommit(ctx context.Context, owner, repo, sha string) (*github.RepositoryCommit, *github.Response, error)
+	ListBranches(ctx context.Context, owner string, repo string, opt *github.ListOptions) ([]*github.Branch, *github.Response, error)
+	GetBranch(ctx context.Context, owner, repo, branch string) (*github.Branch, *github.Response, error)
+	GetCombinedStatus(ctx context.Context, owner, repo, ref string, opt *github.ListOptions) (*github.CombinedStatus, *github.Response, error)
+	CompareCommits(ctx context.Context, owner, repo string, base, head string) (*github.CommitsComparison, *github.Response, error)
+	ListReleases(ctx context.Context, owner, repo string, opt *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error)
+	CreateRelease(ctx context.Context, owner, repo string, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error)
 	ListTags(ctx context.Context, owner, repo string, opt *github.ListOptions) ([]*github.RepositoryTag, *github.Response, error)
 	GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*github.RepositoryRelease, *github.Response, error)
+	GetLatestRelease(ctx context.Context, owner, repo string) (*github.RepositoryRelease, *github.Response, error)
 	EditRelease(ctx context.Context, owner, repo string, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error)
+	DeleteRelease(ctx context.Context, owner, repo string, id int64) (*github.Response, error)
 	ListReleaseAssets(ctx context.Context, owner, repo string, id int64, opt *github.ListOptions) ([]*github.ReleaseAsset, *github.Response, error)
 	DownloadReleaseAsset(ctx context.Context, owner, repo string, id int64) (rc io.ReadCloser, redirectURL string, err error)
 	UploadReleaseAsset(ctx context.Context, owner, repo string, id int64, opt *github.UploadOptions, file *os.File) (*github.ReleaseAsset, *github.Response, error)
@@ -25,7 +35,7 @@ type GithubRepositoriesService interface {
 }
 
 type GithubSearchService interface {
-	Repositories(ctx context.Context, query string, opt *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)
+	Issues(ctx context.Context, query string, opt *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error)
 }
 
 type GithubGitService interface {
@@ -33,6 +43,25 @@ type GithubGitService interface {
 	GetRefs(ctx context.Context, owner string, repo string, ref string) ([]*github.Reference, *github.Response, error)
 	CreateTag(ctx context.Context, owner string, repo string, tag *github.Tag) (*github.Tag, *github.Response, error)
 	CreateRef(ctx context.Context, owner string, repo string, ref *github.Reference) (*github.Reference, *github.Response, error)
+	DeleteRef(ctx context.Context, owner string, repo string, ref string) (*github.Response, error)
+	GetCommit(ctx context.Context, owner string, repo string, sha string) (*github.Commit, *github.Response, error)
+	CreateCommit(ctx context.Context, owner string, repo string, commit *github.Commit) (*github.Commit, *github.Response, error)
+	GetTree(ctx context.Context, owner string, repo string, sha string, recursive bool) (*github.Tree, *github.Response, error)
+	CreateTree(ctx context.Context, owner string, repo string, baseTree string, entries []github.TreeEntry) (*github.Tree, *github.Response, error)
+	GetBlobRaw(ctx context.Context, owner, repo, sha string) ([]byte, *github.Response, error)
+}
+
+type GithubChecksService interface {
+	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opt *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error)
+}
+
+type GithubPullRequestsService interface {
+	Get(ctx context.Context, owner string, repo string, number int) (*github.PullRequest, *github.Response, error)
+	Create(ctx context.Context, owner string, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
+}
+
+type GithubIssuesService interface {
+	AddLabelsToIssue(ctx context.Context, owner string, repo string, number int, labels []string) ([]*github.Label, *github.Response, error)
 }
 
 // GithubClient wraps the github.Client with relevant interfaces.
@@ -40,16 +69,63 @@ type GithubClient struct {
 	Repositories GithubRepositoriesService
 	Search       GithubSearchService
 	Git          GithubGitService
+	Checks       GithubChecksService
+	PullRequests GithubPullRequestsService
+	Issues       GithubIssuesService
 }
 
-func NewGithubClient(ctx context.Context, accessToken string) *GithubClient {
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+// NewGithubClient creates a GithubClient authenticated as the configured GitHub App, with tokens
+// scoped to the repository of each request, or with the configured access token otherwise.
+// GithubBaseURL is optional and allows to target a GitHub Enterprise API instead of github.com.
+func NewGithubClient(ctx context.Context, cfg *MatterbuildConfig) (*GithubClient, error) {
+	httpClient, err := newGithubHTTPClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newGithubAPIClient(httpClient, cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &GithubClient{
 		Repositories: client.Repositories,
 		Search:       client.Search,
 		Git:          client.Git,
+		Checks:       client.Checks,
+		PullRequests: client.PullRequests,
+		Issues:       client.Issues,
+	}, nil
+}
+
+// newGithubAPIClient creates a github.Client for the configured API, github.com if
+// GithubBaseURL is empty.
+func newGithubAPIClient(httpClient *http.Client, cfg *MatterbuildConfig) (*github.Client, error) {
+	if cfg.GithubBaseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	uploadURL := cfg.GithubUploadURL
+	if uploadURL == "" {
+		uploadURL = getGithubUploadURL(cfg.GithubBaseURL)
+	}
+
+	client, err := github.NewEnterpriseClient(cfg.GithubBaseURL, uploadURL, httpClient)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create github client for %s", cfg.GithubBaseURL)
 	}
+
+	return client, nil
+}
+
+// getGithubUploadURL returns the URL release assets are uploaded to for the given GitHub
+// Enterprise API, e.g. https://github.example.com/api/uploads/ for
+// https://github.example.com/api/v3/. Other URLs are returned as is.
+func getGithubUploadURL(baseURL string) string {
+	trimmed := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(trimmed, "/api/v3") {
+		return baseURL
+	}
+
+	return strings.TrimSuffix(trimmed, "/api/v3") + "/api/uploads/"
 }
@@ -0,0 +1,34 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGithubAPIClient(t *testing.T) {
+	client, err := newGithubAPIClient(http.DefaultClient, &MatterbuildConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "https://api.github.com/", client.BaseURL.String())
+	require.Equal(t, "https://uploads.github.com/", client.UploadURL.String())
+
+	client, err = newGithubAPIClient(http.DefaultClient, &MatterbuildConfig{GithubBaseURL: "https://github.example.com/api/v3"})
+	require.NoError(t, err)
+	require.Equal(t, "https://github.example.com/api/v3/", client.BaseURL.String())
+	require.Equal(t, "https://github.example.com/api/uploads/", client.UploadURL.String())
+
+	client, err = newGithubAPIClient(http.DefaultClient, &MatterbuildConfig{
+		GithubBaseURL:   "https://github.example.com/api/v3/",
+		GithubUploadURL: "https://uploads.example.com/",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://uploads.example.com/", client.UploadURL.String())
+
+	client, err = newGithubAPIClient(http.DefaultClient, &MatterbuildConfig{GithubBaseURL: "http://127.0.0.1:8080"})
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:8080/", client.UploadURL.String())
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/github"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+const (
+	githubSignatureHeader = "X-Hub-Signature-256"
+
+	// maxGithubWebhookPayloadSize is the maximum size of a webhook payload accepted by GitHub.
+	maxGithubWebhookPayloadSize = 25 << 20
+)
+
+// releaseNotifier wakes the plugin cuts waiting for a release of a repository.
+type releaseNotifier struct {
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{} // By lowercase owner/repo
+}
+
+var pluginReleaseNotifier = newReleaseNotifier()
+
+func newReleaseNotifier() *releaseNotifier {
+	return &releaseNotifier{waiters: make(map[string]map[chan struct{}]struct{})}
+}
+
+func releaseNotifierKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// wait returns a channel receiving a value whenever a release event is received for the given
+// repository, and a function to call once done waiting.
+func (n *releaseNotifier) wait(owner, repo string) (<-chan struct{}, func()) {
+	key := releaseNotifierKey(owner, repo)
+	ch := make(chan struct{}, 1)
+
+	n.mu.Lock()
+	if n.waiters[key] == nil {
+		n.waiters[key] = make(map[chan struct{}]struct{})
+	}
+	n.waiters[key][ch] = struct{}{}
+	n.mu.Unlock()
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		delete(n.waiters[key], ch)
+		if len(n.waiters[key]) == 0 {
+			delete(n.waiters, key)
+		}
+	}
+}
+
+// notify wakes the waiters of the given repository. Returns the number of waiters woken.
+func (n *releaseNotifier) notify(owner, repo string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	waiters := n.waiters[releaseNotifierKey(owner, repo)]
+	for ch := range waiters {
+		// A pending wake up is enough
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(waiters)
+}
+
+// verifyGithubSignature checks the X-Hub-Signature-256 header of a webhook payload.
+func verifyGithubSignature(secret, signature string, payload []byte) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("missing sha256 signature")
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
+
+// githubWebhookHandler receives the GitHub webhooks of the plugin repositories. On release events,
+// it wakes the plugin cuts waiting for their release asset, and cuts the published releases of
+// the repositories enabled for automatic releases.
+func githubWebhookHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if Cfg.GithubWebhookSecret == "" {
+		http.Error(w, "GitHub webhooks are not configured", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxGithubWebhookPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = verifyGithubSignature(Cfg.GithubWebhookSecret, r.Header.Get(githubSignatureHeader), payload); err != nil {
+		LogError("rejected github webhook err=%s", err.Error())
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+	if eventType != "release" {
+		// e.g. the ping event sent when the webhook is created
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		http.Error(w, "failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	releaseEvent := event.(*github.ReleaseEvent)
+	repo := releaseEvent.GetRepo()
+	woken := pluginReleaseNotifier.notify(repo.GetOwner().GetLogin(), repo.GetName())
+	LogInfo("Received %s release event for %s %s, %d plugin cut(s) woken", releaseEvent.GetAction(), repo.GetFullName(), releaseEvent.GetRelease().GetTagName(), woken)
+
+	if releaseEvent.GetAction() == "published" && isAutoReleaseRepository(Cfg, repo.GetOwner().GetLogin(), repo.GetName()) {
+		ctx := context.Background()
+		client, err := NewGithubClient(ctx, Cfg)
+		if err != nil {
+			LogError("failed to create github client err=%s", err.Error())
+			http.Error(w, "failed to create github client", http.StatusInternalServerError)
+			return
+		}
+
+		if reason := autoReleasePlugin(ctx, Cfg, client, releaseEvent); reason != "" {
+			LogInfo("Skipped automatic release of %s %s: %s", repo.GetFullName(), releaseEvent.GetRelease().GetTagName(), reason)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseNotifier(t *testing.T) {
+	notifier := newReleaseNotifier()
+
+	wake1, stop1 := notifier.wait("mattermost", "mattermost-plugin-demo")
+	wake2, stop2 := notifier.wait("mattermost", "mattermost-plugin-demo")
+	_, stop3 := notifier.wait("mattermost", "mattermost-plugin-jira")
+	defer stop3()
+
+	require.Equal(t, 2, notifier.notify("Mattermost", "mattermost-plugin-demo"))
+	require.Equal(t, 2, notifier.notify("mattermost", "mattermost-plugin-demo"), "should not block on pending wake ups")
+	require.Len(t, wake1, 1)
+	require.Len(t, wake2, 1)
+
+	stop1()
+	stop2()
+	require.Equal(t, 0, notifier.notify("mattermost", "mattermost-plugin-demo"))
+	require.NotContains(t, notifier.waiters, "mattermost/mattermost-plugin-demo")
+}
+
+func TestGithubWebhookHandler(t *testing.T) {
+	Cfg = &MatterbuildConfig{GithubWebhookSecret: "secret"}
+
+	payload := `{"action": "published", "release": {"tag_name": "v1.0.0"}, "repository": {"name": "mattermost-plugin-demo", "full_name": "mattermost/mattermost-plugin-demo", "owner": {"login": "mattermost"}}}`
+	sign := func(secret, payload string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(payload))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	send := func(event, signature, payload string) int {
+		r := httptest.NewRequest(http.MethodPost, "/github_webhook", strings.NewReader(payload))
+		r.Header.Set("X-GitHub-Event", event)
+		r.Header.Set("Content-Type", "application/json")
+		if signature != "" {
+			r.Header.Set("X-Hub-Signature-256", signature)
+		}
+
+		w := httptest.NewRecorder()
+		githubWebhookHandler(w, r, nil)
+		return w.Code
+	}
+
+	t.Run("release event wakes the waiters", func(t *testing.T) {
+		wake, stop := pluginReleaseNotifier.wait("mattermost", "mattermost-plugin-demo")
+		defer stop()
+
+		require.Equal(t, http.StatusNoContent, send("release", sign("secret", payload), payload))
+		require.Len(t, wake, 1)
+	})
+
+	t.Run("other events are ignored", func(t *testing.T) {
+		wake, stop := pluginReleaseNotifier.wait("mattermost", "mattermost-plugin-demo")
+		defer stop()
+
+		require.Equal(t, http.StatusNoContent, send("ping", sign("secret", `{"zen": "Keep it simple."}`), `{"zen": "Keep it simple."}`))
+		require.Empty(t, wake)
+	})
+
+	t.Run("invalid signatures are rejected", func(t *testing.T) {
+		wake, stop := pluginReleaseNotifier.wait("mattermost", "mattermost-plugin-demo")
+		defer stop()
+
+		require.Equal(t, http.StatusUnauthorized, send("release", "", payload))
+		require.Equal(t, http.StatusUnauthorized, send("release", sign("other", payload), payload))
+		require.Equal(t, http.StatusUnauthorized, send("release", "sha256=zz", payload))
+		require.Equal(t, http.StatusUnauthorized, send("release", sign("secret", payload), payload+" "))
+		require.Empty(t, wake)
+	})
+
+	t.Run("webhooks not configured", func(t *testing.T) {
+		Cfg.GithubWebhookSecret = ""
+		defer func() { Cfg.GithubWebhookSecret = "secret" }()
+
+		require.Equal(t, http.StatusNotFound, send("release", sign("", payload), payload))
+	})
+}
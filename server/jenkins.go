@@ -37,7 +37,7 @@ func getJenkins(jenkinsUser, jenkinsToken, jenkinsURL string) (*gojenkins.Jenkin
 }
 
 // CutRelease run the Jenkins job to cut the release
-func CutRelease(release string, rc string, isFirstMinorRelease bool, backportRelease bool,
+func CutRelease(version *ReleaseVersion, backportRelease bool,
 	isDryRun bool, legacy bool, server string, webapp string) *AppError {
 	var jobName string
 	if legacy {
@@ -54,43 +54,9 @@ func CutRelease(release string, rc string, isFirstMinorRelease bool, backportRel
 		return NewError("There is a release job running.", nil)
 	}
 
-	shortRelease := release[:len(release)-2]
-	releaseBranch := "release-" + shortRelease
-
-	var fullRelease string
-	var rcpart string
-	if rc == "" {
-		fullRelease = release
-		rcpart = ""
-	} else {
-		fullRelease = release + "-" + rc
-		rcpart = "-" + rc
-	}
-
-	isFirstMinorReleaseStr := "false"
-	if isFirstMinorRelease {
-		isFirstMinorReleaseStr = "true"
-	}
-
-	isDryRunStr := "false"
-	if isDryRun {
-		isDryRunStr = "true"
-	}
-
-	isDotReleaseStr := "false"
-	if backportRelease {
-		isDotReleaseStr = "true"
-	}
-
-	parameters := map[string]string{
-		"MM_VERSION":             release,
-		"MM_RC":                  rcpart,
-		"IS_FIRST_MINOR_RELEASE": isFirstMinorReleaseStr,
-		"IS_DRY_RUN":             isDryRunStr,
-		"IS_DOT_RELEASE":         isDotReleaseStr,
-		"IS_BACKPORT":            isDotReleaseStr,
-		"PIP_BRANCH":             releaseBranch,
-	}
+	releaseBranch := version.Branch()
+	fullRelease := version.String()
+	parameters := getCutReleaseParameters(version, backportRelease, isDryRun)
 
 	if server != "" {
 		parameters["MM_BUILDER_SERVER_DOCKER"] = server
@@ -126,6 +92,50 @@ func CutRelease(release string, rc string, isFirstMinorRelease bool, backportRel
 	return nil
 }
 
+// getCutReleaseParameters returns the parameters of the Jenkins release job for the given version.
+// IS_FIRST_MINOR_RELEASE is true when the job must create the release branch, i.e. for the
+// first of the alpha1, beta1 or rc1 of a minor release, and no longer for every rc1.
+func getCutReleaseParameters(version *ReleaseVersion, backportRelease bool, isDryRun bool) map[string]string {
+	isFirstMinorReleaseStr := "false"
+	if version.IsFirstMinorRelease() {
+		isFirstMinorReleaseStr = "true"
+	}
+
+	isDryRunStr := "false"
+	if isDryRun {
+		isDryRunStr = "true"
+	}
+
+	isDotReleaseStr := "false"
+	if backportRelease {
+		isDotReleaseStr = "true"
+	}
+
+	parameters := map[string]string{
+		"MM_VERSION":             version.Release(),
+		"MM_RC":                  "",
+		"MM_BETA":                "",
+		"MM_ALPHA":               "",
+		"IS_FIRST_MINOR_RELEASE": isFirstMinorReleaseStr,
+		"IS_DRY_RUN":             isDryRunStr,
+		"IS_DOT_RELEASE":         isDotReleaseStr,
+		"IS_BACKPORT":            isDotReleaseStr,
+		"PIP_BRANCH":             version.Branch(),
+	}
+
+	// Each kind of pre-release is passed in its own parameter so the job can tell them apart.
+	switch version.Kind {
+	case PrereleaseRC:
+		parameters["MM_RC"] = version.Prerelease()
+	case PrereleaseBeta:
+		parameters["MM_BETA"] = version.Prerelease()
+	case PrereleaseAlpha:
+		parameters["MM_ALPHA"] = version.Prerelease()
+	}
+
+	return parameters
+}
+
 func getJob(name, jenkinsUser, jenkinsToken, jenkinsURL string) (*gojenkins.Job, *AppError) {
 	jenkins, appErr := getJenkins(jenkinsUser, jenkinsToken, jenkinsURL)
 	if appErr != nil {
@@ -0,0 +1,64 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCutReleaseParameters(t *testing.T) {
+	t.Run("first release candidate", func(t *testing.T) {
+		version, err := ParseReleaseVersion("5.37.0-rc1")
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]string{
+			"MM_VERSION":             "5.37.0",
+			"MM_RC":                  "-rc1",
+			"MM_BETA":                "",
+			"MM_ALPHA":               "",
+			"IS_FIRST_MINOR_RELEASE": "true",
+			"IS_DRY_RUN":             "false",
+			"IS_DOT_RELEASE":         "false",
+			"IS_BACKPORT":            "false",
+			"PIP_BRANCH":             "release-5.37",
+		}, getCutReleaseParameters(version, false, false))
+	})
+
+	t.Run("backport final release", func(t *testing.T) {
+		version, err := ParseReleaseVersion("5.36.10")
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]string{
+			"MM_VERSION":             "5.36.10",
+			"MM_RC":                  "",
+			"MM_BETA":                "",
+			"MM_ALPHA":               "",
+			"IS_FIRST_MINOR_RELEASE": "false",
+			"IS_DRY_RUN":             "true",
+			"IS_DOT_RELEASE":         "true",
+			"IS_BACKPORT":            "true",
+			"PIP_BRANCH":             "release-5.36",
+		}, getCutReleaseParameters(version, true, true))
+	})
+
+	t.Run("beta and alpha releases", func(t *testing.T) {
+		version, err := ParseReleaseVersion("6.0.0-beta2")
+		require.NoError(t, err)
+
+		parameters := getCutReleaseParameters(version, false, false)
+		require.Equal(t, "", parameters["MM_RC"])
+		require.Equal(t, "-beta2", parameters["MM_BETA"])
+		require.Equal(t, "", parameters["MM_ALPHA"])
+
+		version, err = ParseReleaseVersion("6.0.0-alpha1")
+		require.NoError(t, err)
+
+		parameters = getCutReleaseParameters(version, false, false)
+		require.Equal(t, "", parameters["MM_RC"])
+		require.Equal(t, "", parameters["MM_BETA"])
+		require.Equal(t, "-alpha1", parameters["MM_ALPHA"])
+	})
+}
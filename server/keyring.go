@@ -0,0 +1,150 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// pluginKeyring holds the keys plugin signatures are verified against, along with the
+// validity window of the configured verification key each of them comes from.
+type pluginKeyring struct {
+	openpgp.EntityList
+	windows map[uint64]*PluginVerificationKey // By primary key ID
+}
+
+// loadPluginPublicKeyring loads the keys plugin signatures are verified against. The configured
+// PluginVerificationKeys take precedence, each only accepting signatures created within its
+// validity window. Without them, the single PluginSigningPublicKeyPath is used if set, and the
+// Mattermost production key otherwise.
+func loadPluginPublicKeyring(cfg *MatterbuildConfig) (*pluginKeyring, error) {
+	if len(cfg.PluginVerificationKeys) == 0 {
+		publicKey := mattermostPluginPublicKey
+		if cfg.PluginSigningPublicKeyPath != "" {
+			var err error
+			publicKey, err = os.ReadFile(cfg.PluginSigningPublicKeyPath)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read public key")
+			}
+		}
+
+		entities, err := readArmoredPublicKeys(publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pluginKeyring{EntityList: entities}, nil
+	}
+
+	keyring := &pluginKeyring{windows: make(map[uint64]*PluginVerificationKey)}
+	for _, key := range cfg.PluginVerificationKeys {
+		publicKey, err := os.ReadFile(key.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read public key %s", key.Path)
+		}
+
+		entities, err := readArmoredPublicKeys(publicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load public key %s", key.Path)
+		}
+
+		for _, entity := range entities {
+			keyring.windows[entity.PrimaryKey.KeyId] = key
+		}
+		keyring.EntityList = append(keyring.EntityList, entities...)
+	}
+
+	return keyring, nil
+}
+
+func readArmoredPublicKeys(publicKey []byte) (openpgp.EntityList, error) {
+	block, err := armor.Decode(bytes.NewReader(publicKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode public key")
+	}
+
+	keyring, err := openpgp.ReadKeyRing(block.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read public key")
+	}
+
+	return keyring, nil
+}
+
+// verifySignatures verifies plugin files, assumes signatures are <filepath>.sig.
+// Returns the ID of the key that signed each file.
+func verifySignatures(keyring *pluginKeyring, pluginFilePaths []string) (map[string]string, error) {
+	signers := make(map[string]string, len(pluginFilePaths))
+	for _, pluginFilePath := range pluginFilePaths {
+		keyID, err := verifySignature(keyring, pluginFilePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify %s", pluginFilePath)
+		}
+
+		LogInfo("Signature of %s verified with key %s", pluginFilePath, keyID)
+		signers[pluginFilePath] = keyID
+	}
+
+	return signers, nil
+}
+
+// verifySignature verifies a plugin file against its <filepath>.sig signature, made by a key
+// of the keyring within the validity window of that key. Returns the ID of the key.
+func verifySignature(keyring *pluginKeyring, pluginFilePath string) (string, error) {
+	signedFile, err := os.Open(pluginFilePath)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read signed file")
+	}
+	defer signedFile.Close()
+
+	// Assume signature is always <filepath>.sig
+	signature, err := os.ReadFile(fmt.Sprintf("%s.sig", pluginFilePath))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read signature file")
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(keyring, signedFile, bytes.NewReader(signature))
+	if err != nil {
+		return "", errors.Wrap(err, "error while checking the signature")
+	}
+
+	keyID := signer.PrimaryKey.KeyIdString()
+	if window, ok := keyring.windows[signer.PrimaryKey.KeyId]; ok {
+		createdAt, err := getSignatureCreationTime(signature)
+		if err != nil {
+			return "", err
+		}
+
+		if !window.IsValidAt(createdAt) {
+			return "", errors.Errorf("signature created at %s, outside of the validity window of key %s", createdAt.UTC().Format(time.RFC3339), keyID)
+		}
+	}
+
+	return keyID, nil
+}
+
+// getSignatureCreationTime returns the creation time of a detached signature.
+func getSignatureCreationTime(signature []byte) (time.Time, error) {
+	p, err := packet.Read(bytes.NewReader(signature))
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to read the signature")
+	}
+
+	switch sig := p.(type) {
+	case *packet.Signature:
+		return sig.CreationTime, nil
+	case *packet.SignatureV3:
+		return sig.CreationTime, nil
+	default:
+		return time.Time{}, errors.New("not a signature")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestPluginVerificationKeyIsValidAt(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	require.True(t, (&PluginVerificationKey{}).IsValidAt(now))
+	require.True(t, (&PluginVerificationKey{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}).IsValidAt(now))
+	require.False(t, (&PluginVerificationKey{NotBefore: now.Add(time.Hour)}).IsValidAt(now))
+	require.False(t, (&PluginVerificationKey{NotAfter: now.Add(-time.Hour)}).IsValidAt(now))
+}
+
+func TestLoadPluginPublicKeyring(t *testing.T) {
+	now := time.Now()
+
+	t.Run("defaults to the production key", func(t *testing.T) {
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{})
+		require.NoError(t, err)
+		require.Len(t, keyring.EntityList, 1)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadPluginPublicKeyring(&MatterbuildConfig{PluginSigningPublicKeyPath: filepath.Join(t.TempDir(), "missing.asc")})
+		require.Error(t, err)
+	})
+
+	t.Run("verification keys take precedence", func(t *testing.T) {
+		_, publicKeyPath, keyID := writeTestSigningKey(t, t.TempDir())
+
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginSigningPublicKeyPath: filepath.Join(t.TempDir(), "missing.asc"),
+			PluginVerificationKeys:     []*PluginVerificationKey{{Path: publicKeyPath}},
+		})
+		require.NoError(t, err)
+		require.Len(t, keyring.EntityList, 1)
+		require.Equal(t, keyID, keyring.EntityList[0].PrimaryKey.KeyIdString())
+	})
+
+	t.Run("keys outside of their validity window are kept for older signatures", func(t *testing.T) {
+		_, oldKeyPath, _ := writeTestSigningKey(t, t.TempDir())
+		_, newKeyPath, _ := writeTestSigningKey(t, t.TempDir())
+
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginVerificationKeys: []*PluginVerificationKey{
+				{Path: oldKeyPath, NotAfter: now.Add(-time.Hour)},
+				{Path: newKeyPath, NotBefore: now.Add(-time.Hour)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, keyring.EntityList, 2)
+		require.Len(t, keyring.windows, 2)
+	})
+
+	t.Run("missing verification key", func(t *testing.T) {
+		_, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginVerificationKeys: []*PluginVerificationKey{{Path: filepath.Join(t.TempDir(), "missing.asc")}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	oldDir := t.TempDir()
+	oldPrivateKeyPath, oldPublicKeyPath, oldKeyID := writeTestSigningKey(t, oldDir)
+	newDir := t.TempDir()
+	newPrivateKeyPath, newPublicKeyPath, newKeyID := writeTestSigningKey(t, newDir)
+
+	oldSigner, err := newLocalSigner(oldPrivateKeyPath, "")
+	require.NoError(t, err)
+	newSigner, err := newLocalSigner(newPrivateKeyPath, "")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	oldPluginPath := filepath.Join(dir, "old.tar.gz")
+	newPluginPath := filepath.Join(dir, "new.tar.gz")
+	require.NoError(t, os.WriteFile(oldPluginPath, []byte("old"), 0600))
+	require.NoError(t, os.WriteFile(newPluginPath, []byte("new"), 0600))
+	require.NoError(t, oldSigner.Sign(context.Background(), []string{oldPluginPath}))
+	require.NoError(t, newSigner.Sign(context.Background(), []string{newPluginPath}))
+
+	t.Run("both keys during the transition", func(t *testing.T) {
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginVerificationKeys: []*PluginVerificationKey{{Path: oldPublicKeyPath}, {Path: newPublicKeyPath}},
+		})
+		require.NoError(t, err)
+
+		signers, err := verifySignatures(keyring, []string{oldPluginPath, newPluginPath})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{oldPluginPath: oldKeyID, newPluginPath: newKeyID}, signers)
+	})
+
+	t.Run("old key retired", func(t *testing.T) {
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginVerificationKeys: []*PluginVerificationKey{{Path: newPublicKeyPath}},
+		})
+		require.NoError(t, err)
+
+		_, err = verifySignatures(keyring, []string{newPluginPath, oldPluginPath})
+		require.Error(t, err)
+	})
+
+	t.Run("signatures checked against the validity window of their key", func(t *testing.T) {
+		rotation := time.Now().Add(-24 * time.Hour)
+		beforeRotationPath := filepath.Join(dir, "before-rotation.tar.gz")
+		afterRotationPath := filepath.Join(dir, "after-rotation.tar.gz")
+		signTestFileAt(t, oldSigner.entity, beforeRotationPath, rotation.Add(-time.Hour))
+		signTestFileAt(t, oldSigner.entity, afterRotationPath, rotation.Add(time.Hour))
+
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{
+			PluginVerificationKeys: []*PluginVerificationKey{
+				{Path: oldPublicKeyPath, NotAfter: rotation},
+				{Path: newPublicKeyPath, NotBefore: rotation},
+			},
+		})
+		require.NoError(t, err)
+
+		signers, err := verifySignatures(keyring, []string{beforeRotationPath, newPluginPath})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{beforeRotationPath: oldKeyID, newPluginPath: newKeyID}, signers)
+
+		_, err = verifySignature(keyring, afterRotationPath)
+		require.EqualError(t, err, "signature created at "+rotation.Add(time.Hour).UTC().Format(time.RFC3339)+", outside of the validity window of key "+oldKeyID)
+	})
+}
+
+// signTestFileAt writes a file and its signature created at the given time.
+func signTestFileAt(t *testing.T, entity *openpgp.Entity, path string, createdAt time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(path), 0600))
+
+	var signature bytes.Buffer
+	require.NoError(t, openpgp.DetachSign(&signature, entity, strings.NewReader(path), &packet.Config{Time: func() time.Time { return createdAt }}))
+	require.NoError(t, os.WriteFile(path+".sig", signature.Bytes(), 0600))
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPluginDownloadBaseURL = "https://plugins.releases.mattermost.com/release"
+	defaultMarketplaceRepository = "mattermost/mattermost-marketplace"
+	defaultMarketplaceBaseBranch = "production"
+
+	marketplacePluginsFile = "plugins.json"
+)
+
+// marketplaceReviewLabels are added to the Marketplace pull requests.
+var marketplaceReviewLabels = []string{"3: QA Review", "2: Dev Review"}
+
+// marketplacePlugin is an entry of the plugins.json file of the Marketplace.
+type marketplacePlugin struct {
+	HomepageURL     string                                `json:"homepage_url"`
+	IconData        string                                `json:"icon_data"`
+	DownloadURL     string                                `json:"download_url"`
+	ReleaseNotesURL string                                `json:"release_notes_url"`
+	AuthorType      string                                `json:"author_type"`
+	ReleaseStage    string                                `json:"release_stage"`
+	Enterprise      bool                                  `json:"enterprise"`
+	Signature       string                                `json:"signature"` // Base64 encoded
+	RepoName        string                                `json:"repo_name"`
+	Manifest        *model.Manifest                       `json:"manifest"`
+	Platforms       map[string]*marketplacePlatformBundle `json:"platforms,omitempty"`
+	UpdatedAt       time.Time                             `json:"updated_at"`
+}
+
+// marketplacePlatformBundle is a platform specific plugin tar of a Marketplace entry.
+type marketplacePlatformBundle struct {
+	DownloadURL string `json:"download_url"`
+	Signature   string `json:"signature"` // Base64 encoded
+}
+
+// marketplaceOptions are the flags of the Marketplace generator.
+type marketplaceOptions struct {
+	Community  bool // Maintained by the Open Source community rather than by Mattermost
+	Beta       bool
+	Enterprise bool // Requires an enterprise license
+}
+
+// parseMarketplaceOptions parses the value of the --marketplace flag. Returns nil if marketplace is empty.
+func parseMarketplaceOptions(marketplace string, beta, enterprise bool) (*marketplaceOptions, error) {
+	switch marketplace {
+	case "":
+		if beta || enterprise {
+			return nil, errors.New("--beta and --enterprise require --marketplace")
+		}
+		return nil, nil
+	case "official", "community":
+		return &marketplaceOptions{Community: marketplace == "community", Beta: beta, Enterprise: enterprise}, nil
+	default:
+		return nil, errors.Errorf("--marketplace must be official or community, got %q", marketplace)
+	}
+}
+
+// readPluginIconData returns the icon of the given plugin.tar.gz as a data URL, or an empty string
+// if the manifest declares none. The Marketplace only displays SVG icons, others are rejected.
+func readPluginIconData(pluginFilePath string, manifest *model.Manifest) (string, error) {
+	if manifest.IconPath == "" {
+		return "", nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(manifest.IconPath), ".svg") {
+		return "", errors.Errorf("plugin icon %s must be an SVG image", manifest.IconPath)
+	}
+
+	icon, err := readPluginArchiveFile(pluginFilePath, manifest.IconPath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read plugin icon")
+	}
+
+	if !bytes.Contains(icon, []byte("<svg")) {
+		return "", errors.Errorf("plugin icon %s is not an SVG image", manifest.IconPath)
+	}
+
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(icon), nil
+}
+
+// newMarketplacePlugin describes the published release as a Marketplace entry. Signatures of the
+// artifacts are read from dir. The author type and release stage are left to addToMarketplace.
+func newMarketplacePlugin(cfg *MatterbuildConfig, owner, repositoryName, tag string, manifest *model.Manifest, iconData string, release *pluginReleaseManifest, dir string) (*marketplacePlugin, error) {
+	baseURL := strings.TrimSuffix(cfg.PluginDownloadBaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultPluginDownloadBaseURL
+	}
+
+	plugin := &marketplacePlugin{
+		HomepageURL:     manifest.HomepageURL,
+		IconData:        iconData,
+		ReleaseNotesURL: manifest.ReleaseNotesURL,
+		RepoName:        repositoryName,
+		Manifest:        manifest,
+		UpdatedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	if plugin.HomepageURL == "" {
+		plugin.HomepageURL = fmt.Sprintf("https://github.com/%s/%s", owner, repositoryName)
+	}
+	if plugin.ReleaseNotesURL == "" {
+		plugin.ReleaseNotesURL = fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", owner, repositoryName, tag)
+	}
+
+	for _, artifact := range release.Artifacts {
+		signature, err := os.ReadFile(filepath.Join(dir, artifact.Signature))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read signature of %s", artifact.Name)
+		}
+
+		downloadURL := baseURL + "/" + artifact.Name
+		encodedSignature := base64.StdEncoding.EncodeToString(signature)
+		if artifact.Platform == "" {
+			plugin.DownloadURL = downloadURL
+			plugin.Signature = encodedSignature
+			continue
+		}
+
+		if plugin.Platforms == nil {
+			plugin.Platforms = make(map[string]*marketplacePlatformBundle)
+		}
+		plugin.Platforms[artifact.Platform] = &marketplacePlatformBundle{
+			DownloadURL: downloadURL,
+			Signature:   encodedSignature,
+		}
+	}
+
+	return plugin, nil
+}
+
+// addToMarketplace adds the plugin to the given plugins.json content, after the other versions
+// of the same plugin. Entries are kept as-is.
+func addToMarketplace(pluginsJSON []byte, plugin *marketplacePlugin) ([]byte, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(pluginsJSON, &entries); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", marketplacePluginsFile)
+	}
+
+	position := len(entries)
+	for i, entry := range entries {
+		var existing struct {
+			Manifest *model.Manifest `json:"manifest"`
+		}
+		if err := json.Unmarshal(entry, &existing); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse entry %d of %s", i, marketplacePluginsFile)
+		}
+		if existing.Manifest == nil || existing.Manifest.Id != plugin.Manifest.Id {
+			continue
+		}
+
+		if existing.Manifest.Version == plugin.Manifest.Version {
+			return nil, errors.Errorf("version %s of %s is already in the Marketplace", plugin.Manifest.Version, plugin.Manifest.Id)
+		}
+		position = i + 1
+	}
+
+	entry, err := json.Marshal(plugin)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal marketplace entry")
+	}
+
+	entries = append(entries, nil)
+	copy(entries[position+1:], entries[position:])
+	entries[position] = entry
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		return nil, errors.Wrapf(err, "failed to encode %s", marketplacePluginsFile)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// createMarketplacePullRequest commits the plugin to plugins.json of the Marketplace on a new
+// branch, and opens a pull request for review. Returns the URL of the pull request.
+func createMarketplacePullRequest(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, plugin *marketplacePlugin, tag string, opts *marketplaceOptions) (string, error) {
+	marketplaceRepository := cfg.MarketplaceRepository
+	if marketplaceRepository == "" {
+		marketplaceRepository = defaultMarketplaceRepository
+	}
+	split := strings.SplitN(marketplaceRepository, "/", 2)
+	if len(split) != 2 {
+		return "", errors.Errorf("invalid marketplace repository %s, expected owner/name", marketplaceRepository)
+	}
+	owner, repo := split[0], split[1]
+
+	baseBranch := cfg.MarketplaceBaseBranch
+	if baseBranch == "" {
+		baseBranch = defaultMarketplaceBaseBranch
+	}
+
+	entry := *plugin
+	entry.AuthorType = "mattermost"
+	if opts.Community {
+		entry.AuthorType = "community"
+	}
+	entry.ReleaseStage = "production"
+	if opts.Beta {
+		entry.ReleaseStage = "beta"
+	}
+	entry.Enterprise = opts.Enterprise
+
+	baseRef, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+baseBranch)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get marketplace branch %s", baseBranch)
+	}
+	baseCommit, _, err := client.Git.GetCommit(ctx, owner, repo, baseRef.GetObject().GetSHA())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get marketplace commit")
+	}
+	baseTree, _, err := client.Git.GetTree(ctx, owner, repo, baseCommit.GetTree().GetSHA(), false)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get marketplace tree")
+	}
+
+	var pluginsBlobSHA string
+	for _, treeEntry := range baseTree.Entries {
+		if treeEntry.GetPath() == marketplacePluginsFile {
+			pluginsBlobSHA = treeEntry.GetSHA()
+			break
+		}
+	}
+	if pluginsBlobSHA == "" {
+		return "", errors.Errorf("%s not found in %s", marketplacePluginsFile, marketplaceRepository)
+	}
+
+	pluginsJSON, _, err := client.Git.GetBlobRaw(ctx, owner, repo, pluginsBlobSHA)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s", marketplacePluginsFile)
+	}
+
+	updatedPluginsJSON, err := addToMarketplace(pluginsJSON, &entry)
+	if err != nil {
+		return "", err
+	}
+
+	tree, _, err := client.Git.CreateTree(ctx, owner, repo, baseTree.GetSHA(), []github.TreeEntry{{
+		Path:    github.String(marketplacePluginsFile),
+		Mode:    github.String("100644"),
+		Type:    github.String("blob"),
+		Content: github.String(string(updatedPluginsJSON)),
+	}})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create marketplace tree")
+	}
+
+	title := fmt.Sprintf("Add %s of %s to the Marketplace", tag, plugin.RepoName)
+	commit, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: github.String(title),
+		Tree:    tree,
+		Parents: []github.Commit{{SHA: baseCommit.SHA}},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create marketplace commit")
+	}
+
+	branch := fmt.Sprintf("add_%s_%s", plugin.RepoName, tag)
+	if _, _, err = client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}); err != nil {
+		return "", errors.Wrapf(err, "failed to create marketplace branch %s", branch)
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(branch),
+		Base:  github.String(baseBranch),
+		Body:  github.String(fmt.Sprintf("Adds %s of %s, with author type `%s` and release stage `%s`.", tag, plugin.RepoName, entry.AuthorType, entry.ReleaseStage)),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create marketplace pull request")
+	}
+
+	if _, _, err = client.Issues.AddLabelsToIssue(ctx, owner, repo, pr.GetNumber(), marketplaceReviewLabels); err != nil {
+		// The pull request is usable without labels
+		LogError("failed to label marketplace pull request %s err=%s", pr.GetHTMLURL(), err.Error())
+	}
+
+	return pr.GetHTMLURL(), nil
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestParseMarketplaceOptions(t *testing.T) {
+	opts, err := parseMarketplaceOptions("", false, false)
+	require.NoError(t, err)
+	require.Nil(t, opts)
+
+	opts, err = parseMarketplaceOptions("official", true, false)
+	require.NoError(t, err)
+	require.Equal(t, &marketplaceOptions{Beta: true}, opts)
+
+	opts, err = parseMarketplaceOptions("community", false, true)
+	require.NoError(t, err)
+	require.Equal(t, &marketplaceOptions{Community: true, Enterprise: true}, opts)
+
+	_, err = parseMarketplaceOptions("partner", false, false)
+	require.EqualError(t, err, `--marketplace must be official or community, got "partner"`)
+
+	_, err = parseMarketplaceOptions("", true, false)
+	require.EqualError(t, err, "--beta and --enterprise require --marketplace")
+}
+
+func TestReadPluginIconData(t *testing.T) {
+	pluginFilePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	writeTestArchive(t, pluginFilePath, []testArchiveEntry{
+		{Name: "com.mattermost.demo-plugin/assets/icon.svg", Body: "<svg/>"},
+		{Name: "com.mattermost.demo-plugin/assets/icon.png", Body: "\x89PNG"},
+		{Name: "com.mattermost.demo-plugin/assets/fake.svg", Body: "\x89PNG"},
+	})
+
+	iconData, err := readPluginIconData(pluginFilePath, &model.Manifest{})
+	require.NoError(t, err)
+	require.Empty(t, iconData)
+
+	iconData, err = readPluginIconData(pluginFilePath, &model.Manifest{IconPath: "assets/icon.svg"})
+	require.NoError(t, err)
+	require.Equal(t, "data:image/svg+xml;base64,PHN2Zy8+", iconData)
+
+	_, err = readPluginIconData(pluginFilePath, &model.Manifest{IconPath: "assets/icon.png"})
+	require.EqualError(t, err, "plugin icon assets/icon.png must be an SVG image")
+
+	_, err = readPluginIconData(pluginFilePath, &model.Manifest{IconPath: "assets/fake.svg"})
+	require.EqualError(t, err, "plugin icon assets/fake.svg is not an SVG image")
+}
+
+func TestAddToMarketplace(t *testing.T) {
+	pluginsJSON := []byte(`[
+  {"repo_name": "mattermost-plugin-demo", "icon_data": "<svg/>", "manifest": {"id": "com.mattermost.demo-plugin", "version": "0.3.0"}},
+  {"repo_name": "mattermost-plugin-demo", "manifest": {"id": "com.mattermost.demo-plugin", "version": "0.4.0"}},
+  {"repo_name": "mattermost-plugin-jira", "manifest": {"id": "jira", "version": "3.0.0"}, "unknown": true}
+]`)
+
+	plugin := &marketplacePlugin{
+		RepoName: "mattermost-plugin-demo",
+		Manifest: &model.Manifest{Id: "com.mattermost.demo-plugin", Version: "0.4.1"},
+	}
+
+	t.Run("added after the other versions", func(t *testing.T) {
+		updated, err := addToMarketplace(pluginsJSON, plugin)
+		require.NoError(t, err)
+		require.Contains(t, string(updated), `"icon_data": "<svg/>"`)
+		require.Contains(t, string(updated), `"unknown": true`)
+
+		var entries []*marketplacePlugin
+		require.NoError(t, json.Unmarshal(updated, &entries))
+		require.Len(t, entries, 4)
+		require.Equal(t, "0.4.0", entries[1].Manifest.Version)
+		require.Equal(t, "0.4.1", entries[2].Manifest.Version)
+		require.Equal(t, "jira", entries[3].Manifest.Id)
+	})
+
+	t.Run("new plugin", func(t *testing.T) {
+		updated, err := addToMarketplace(pluginsJSON, &marketplacePlugin{
+			RepoName: "mattermost-plugin-new",
+			Manifest: &model.Manifest{Id: "new", Version: "1.0.0"},
+		})
+		require.NoError(t, err)
+
+		var entries []*marketplacePlugin
+		require.NoError(t, json.Unmarshal(updated, &entries))
+		require.Len(t, entries, 4)
+		require.Equal(t, "new", entries[3].Manifest.Id)
+	})
+
+	t.Run("version already added", func(t *testing.T) {
+		_, err := addToMarketplace(pluginsJSON, &marketplacePlugin{
+			Manifest: &model.Manifest{Id: "jira", Version: "3.0.0"},
+		})
+		require.EqualError(t, err, "version 3.0.0 of jira is already in the Marketplace")
+	})
+
+	t.Run("invalid plugins.json", func(t *testing.T) {
+		_, err := addToMarketplace([]byte(`{}`), plugin)
+		require.Error(t, err)
+	})
+}
+
+func TestCreateMarketplacePullRequest(t *testing.T) {
+	ctx := context.Background()
+	cfg := &MatterbuildConfig{}
+	owner := "mattermost"
+	repo := "mattermost-marketplace"
+	tag := "v0.4.1"
+
+	plugin := &marketplacePlugin{
+		RepoName: "mattermost-plugin-demo",
+		Manifest: &model.Manifest{Id: "com.mattermost.demo-plugin", Version: "0.4.1"},
+	}
+
+	setup := func(t *testing.T) (*GithubClient, *mocks.MockGithubGitService, *mocks.MockGithubPullRequestsService, *mocks.MockGithubIssuesService) {
+		ctrl := gomock.NewController(t)
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		pullsMock := mocks.NewMockGithubPullRequestsService(ctrl)
+		issuesMock := mocks.NewMockGithubIssuesService(ctrl)
+
+		gitMock.EXPECT().GetRef(ctx, owner, repo, "heads/production").Return(&github.Reference{Object: &github.GitObject{SHA: github.String("base")}}, nil, nil)
+		gitMock.EXPECT().GetCommit(ctx, owner, repo, "base").Return(&github.Commit{SHA: github.String("base"), Tree: &github.Tree{SHA: github.String("tree")}}, nil, nil)
+		gitMock.EXPECT().GetTree(ctx, owner, repo, "tree", false).Return(&github.Tree{SHA: github.String("tree"), Entries: []github.TreeEntry{
+			{Path: github.String("README.md"), SHA: github.String("readme")},
+			{Path: github.String("plugins.json"), SHA: github.String("plugins")},
+		}}, nil, nil)
+		gitMock.EXPECT().GetBlobRaw(ctx, owner, repo, "plugins").Return([]byte(`[]`), nil, nil)
+
+		return &GithubClient{Git: gitMock, PullRequests: pullsMock, Issues: issuesMock}, gitMock, pullsMock, issuesMock
+	}
+
+	t.Run("pull request created", func(t *testing.T) {
+		client, gitMock, pullsMock, issuesMock := setup(t)
+
+		gitMock.EXPECT().CreateTree(ctx, owner, repo, "tree", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, _ string, entries []github.TreeEntry) (*github.Tree, *github.Response, error) {
+				require.Len(t, entries, 1)
+				require.Equal(t, "plugins.json", entries[0].GetPath())
+
+				var added []*marketplacePlugin
+				require.NoError(t, json.Unmarshal([]byte(entries[0].GetContent()), &added))
+				require.Len(t, added, 1)
+				require.Equal(t, "community", added[0].AuthorType)
+				require.Equal(t, "beta", added[0].ReleaseStage)
+				require.False(t, added[0].Enterprise)
+
+				return &github.Tree{SHA: github.String("newtree")}, nil, nil
+			})
+		gitMock.EXPECT().CreateCommit(ctx, owner, repo, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, commit *github.Commit) (*github.Commit, *github.Response, error) {
+				require.Equal(t, "Add v0.4.1 of mattermost-plugin-demo to the Marketplace", commit.GetMessage())
+				require.Equal(t, "newtree", commit.GetTree().GetSHA())
+				require.Equal(t, "base", commit.Parents[0].GetSHA())
+				return &github.Commit{SHA: github.String("commit")}, nil, nil
+			})
+		gitMock.EXPECT().CreateRef(ctx, owner, repo, &github.Reference{
+			Ref:    github.String("refs/heads/add_mattermost-plugin-demo_v0.4.1"),
+			Object: &github.GitObject{SHA: github.String("commit")},
+		}).Return(nil, nil, nil)
+		pullsMock.EXPECT().Create(ctx, owner, repo, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+				require.Equal(t, "add_mattermost-plugin-demo_v0.4.1", pull.GetHead())
+				require.Equal(t, "production", pull.GetBase())
+				return &github.PullRequest{Number: github.Int(42), HTMLURL: github.String("https://github.com/mattermost/mattermost-marketplace/pull/42")}, nil, nil
+			})
+		issuesMock.EXPECT().AddLabelsToIssue(ctx, owner, repo, 42, []string{"3: QA Review", "2: Dev Review"}).Return(nil, nil, nil)
+
+		url, err := createMarketplacePullRequest(ctx, cfg, client, plugin, tag, &marketplaceOptions{Community: true, Beta: true})
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/mattermost/mattermost-marketplace/pull/42", url)
+		require.Empty(t, plugin.AuthorType, "the cut plugin entry should be left untouched")
+	})
+
+	t.Run("branch already exists", func(t *testing.T) {
+		client, gitMock, _, _ := setup(t)
+
+		gitMock.EXPECT().CreateTree(ctx, owner, repo, "tree", gomock.Any()).Return(&github.Tree{SHA: github.String("newtree")}, nil, nil)
+		gitMock.EXPECT().CreateCommit(ctx, owner, repo, gomock.Any()).Return(&github.Commit{SHA: github.String("commit")}, nil, nil)
+		gitMock.EXPECT().CreateRef(ctx, owner, repo, gomock.Any()).Return(nil, nil, errors.New("Reference already exists"))
+
+		_, err := createMarketplacePullRequest(ctx, cfg, client, plugin, tag, &marketplaceOptions{})
+		require.EqualError(t, err, "failed to create marketplace branch add_mattermost-plugin-demo_v0.4.1: Reference already exists")
+	})
+}
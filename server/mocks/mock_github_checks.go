@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/matterbuild/server (interfaces: GithubChecksService)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+)
+
+// MockGithubChecksService is a mock of GithubChecksService interface.
+type MockGithubChecksService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGithubChecksServiceMockRecorder
+}
+
+// MockGithubChecksServiceMockRecorder is the mock recorder for MockGithubChecksService.
+type MockGithubChecksServiceMockRecorder struct {
+	mock *MockGithubChecksService
+}
+
+// NewMockGithubChecksService creates a new mock instance.
+func NewMockGithubChecksService(ctrl *gomock.Controller) *MockGithubChecksService {
+	mock := &MockGithubChecksService{ctrl: ctrl}
+	mock.recorder = &MockGithubChecksServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGithubChecksService) EXPECT() *MockGithubChecksServiceMockRecorder {
+	return m.recorder
+}
+
+// ListCheckRunsForRef mocks base method.
+func (m *MockGithubChecksService) ListCheckRunsForRef(arg0 context.Context, arg1, arg2, arg3 string, arg4 *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCheckRunsForRef", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*github.ListCheckRunsResults)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListCheckRunsForRef indicates an expected call of ListCheckRunsForRef.
+func (mr *MockGithubChecksServiceMockRecorder) ListCheckRunsForRef(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCheckRunsForRef", reflect.TypeOf((*MockGithubChecksService)(nil).ListCheckRunsForRef), arg0, arg1, arg2, arg3, arg4)
+}
@@ -35,6 +35,22 @@ func (m *MockGithubGitService) EXPECT() *MockGithubGitServiceMockRecorder {
 	return m.recorder
 }
 
+// CreateCommit mocks base method.
+func (m *MockGithubGitService) CreateCommit(arg0 context.Context, arg1, arg2 string, arg3 *github.Commit) (*github.Commit, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.Commit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateCommit indicates an expected call of CreateCommit.
+func (mr *MockGithubGitServiceMockRecorder) CreateCommit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommit", reflect.TypeOf((*MockGithubGitService)(nil).CreateCommit), arg0, arg1, arg2, arg3)
+}
+
 // CreateRef mocks base method.
 func (m *MockGithubGitService) CreateRef(arg0 context.Context, arg1, arg2 string, arg3 *github.Reference) (*github.Reference, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -67,6 +83,69 @@ func (mr *MockGithubGitServiceMockRecorder) CreateTag(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockGithubGitService)(nil).CreateTag), arg0, arg1, arg2, arg3)
 }
 
+// CreateTree mocks base method.
+func (m *MockGithubGitService) CreateTree(arg0 context.Context, arg1, arg2, arg3 string, arg4 []github.TreeEntry) (*github.Tree, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTree", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*github.Tree)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTree indicates an expected call of CreateTree.
+func (mr *MockGithubGitServiceMockRecorder) CreateTree(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockGithubGitService)(nil).CreateTree), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteRef mocks base method.
+func (m *MockGithubGitService) DeleteRef(arg0 context.Context, arg1, arg2, arg3 string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRef", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRef indicates an expected call of DeleteRef.
+func (mr *MockGithubGitServiceMockRecorder) DeleteRef(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRef", reflect.TypeOf((*MockGithubGitService)(nil).DeleteRef), arg0, arg1, arg2, arg3)
+}
+
+// GetBlobRaw mocks base method.
+func (m *MockGithubGitService) GetBlobRaw(arg0 context.Context, arg1, arg2, arg3 string) ([]byte, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlobRaw", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBlobRaw indicates an expected call of GetBlobRaw.
+func (mr *MockGithubGitServiceMockRecorder) GetBlobRaw(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlobRaw", reflect.TypeOf((*MockGithubGitService)(nil).GetBlobRaw), arg0, arg1, arg2, arg3)
+}
+
+// GetCommit mocks base method.
+func (m *MockGithubGitService) GetCommit(arg0 context.Context, arg1, arg2, arg3 string) (*github.Commit, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.Commit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCommit indicates an expected call of GetCommit.
+func (mr *MockGithubGitServiceMockRecorder) GetCommit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommit", reflect.TypeOf((*MockGithubGitService)(nil).GetCommit), arg0, arg1, arg2, arg3)
+}
+
 // GetRef mocks base method.
 func (m *MockGithubGitService) GetRef(arg0 context.Context, arg1, arg2, arg3 string) (*github.Reference, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -98,3 +177,19 @@ func (mr *MockGithubGitServiceMockRecorder) GetRefs(arg0, arg1, arg2, arg3 inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefs", reflect.TypeOf((*MockGithubGitService)(nil).GetRefs), arg0, arg1, arg2, arg3)
 }
+
+// GetTree mocks base method.
+func (m *MockGithubGitService) GetTree(arg0 context.Context, arg1, arg2, arg3 string, arg4 bool) (*github.Tree, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTree", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*github.Tree)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTree indicates an expected call of GetTree.
+func (mr *MockGithubGitServiceMockRecorder) GetTree(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockGithubGitService)(nil).GetTree), arg0, arg1, arg2, arg3, arg4)
+}
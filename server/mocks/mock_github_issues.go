@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/matterbuild/server (interfaces: GithubIssuesService)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+)
+
+// MockGithubIssuesService is a mock of GithubIssuesService interface.
+type MockGithubIssuesService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGithubIssuesServiceMockRecorder
+}
+
+// MockGithubIssuesServiceMockRecorder is the mock recorder for MockGithubIssuesService.
+type MockGithubIssuesServiceMockRecorder struct {
+	mock *MockGithubIssuesService
+}
+
+// NewMockGithubIssuesService creates a new mock instance.
+func NewMockGithubIssuesService(ctrl *gomock.Controller) *MockGithubIssuesService {
+	mock := &MockGithubIssuesService{ctrl: ctrl}
+	mock.recorder = &MockGithubIssuesServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGithubIssuesService) EXPECT() *MockGithubIssuesServiceMockRecorder {
+	return m.recorder
+}
+
+// AddLabelsToIssue mocks base method.
+func (m *MockGithubIssuesService) AddLabelsToIssue(arg0 context.Context, arg1, arg2 string, arg3 int, arg4 []string) ([]*github.Label, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLabelsToIssue", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*github.Label)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddLabelsToIssue indicates an expected call of AddLabelsToIssue.
+func (mr *MockGithubIssuesServiceMockRecorder) AddLabelsToIssue(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLabelsToIssue", reflect.TypeOf((*MockGithubIssuesService)(nil).AddLabelsToIssue), arg0, arg1, arg2, arg3, arg4)
+}
@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/matterbuild/server (interfaces: GithubPullRequestsService)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+)
+
+// MockGithubPullRequestsService is a mock of GithubPullRequestsService interface.
+type MockGithubPullRequestsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGithubPullRequestsServiceMockRecorder
+}
+
+// MockGithubPullRequestsServiceMockRecorder is the mock recorder for MockGithubPullRequestsService.
+type MockGithubPullRequestsServiceMockRecorder struct {
+	mock *MockGithubPullRequestsService
+}
+
+// NewMockGithubPullRequestsService creates a new mock instance.
+func NewMockGithubPullRequestsService(ctrl *gomock.Controller) *MockGithubPullRequestsService {
+	mock := &MockGithubPullRequestsService{ctrl: ctrl}
+	mock.recorder = &MockGithubPullRequestsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGithubPullRequestsService) EXPECT() *MockGithubPullRequestsServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockGithubPullRequestsService) Create(arg0 context.Context, arg1, arg2 string, arg3 *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockGithubPullRequestsServiceMockRecorder) Create(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockGithubPullRequestsService)(nil).Create), arg0, arg1, arg2, arg3)
+}
+
+// Get mocks base method.
+func (m *MockGithubPullRequestsService) Get(arg0 context.Context, arg1, arg2 string, arg3 int) (*github.PullRequest, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockGithubPullRequestsServiceMockRecorder) Get(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockGithubPullRequestsService)(nil).Get), arg0, arg1, arg2, arg3)
+}
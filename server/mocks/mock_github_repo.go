@@ -37,6 +37,53 @@ func (m *MockGithubRepositoriesService) EXPECT() *MockGithubRepositoriesServiceM
 	return m.recorder
 }
 
+// CompareCommits mocks base method.
+func (m *MockGithubRepositoriesService) CompareCommits(arg0 context.Context, arg1, arg2, arg3, arg4 string) (*github.CommitsComparison, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareCommits", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*github.CommitsComparison)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CompareCommits indicates an expected call of CompareCommits.
+func (mr *MockGithubRepositoriesServiceMockRecorder) CompareCommits(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareCommits", reflect.TypeOf((*MockGithubRepositoriesService)(nil).CompareCommits), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateRelease mocks base method.
+func (m *MockGithubRepositoriesService) CreateRelease(arg0 context.Context, arg1, arg2 string, arg3 *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRelease", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.RepositoryRelease)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateRelease indicates an expected call of CreateRelease.
+func (mr *MockGithubRepositoriesServiceMockRecorder) CreateRelease(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRelease", reflect.TypeOf((*MockGithubRepositoriesService)(nil).CreateRelease), arg0, arg1, arg2, arg3)
+}
+
+// DeleteRelease mocks base method.
+func (m *MockGithubRepositoriesService) DeleteRelease(arg0 context.Context, arg1, arg2 string, arg3 int64) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelease", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRelease indicates an expected call of DeleteRelease.
+func (mr *MockGithubRepositoriesServiceMockRecorder) DeleteRelease(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelease", reflect.TypeOf((*MockGithubRepositoriesService)(nil).DeleteRelease), arg0, arg1, arg2, arg3)
+}
+
 // DeleteReleaseAsset mocks base method.
 func (m *MockGithubRepositoriesService) DeleteReleaseAsset(arg0 context.Context, arg1, arg2 string, arg3 int64) (*github.Response, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +147,38 @@ func (mr *MockGithubRepositoriesServiceMockRecorder) Get(arg0, arg1, arg2 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockGithubRepositoriesService)(nil).Get), arg0, arg1, arg2)
 }
 
+// GetBranch mocks base method.
+func (m *MockGithubRepositoriesService) GetBranch(arg0 context.Context, arg1, arg2, arg3 string) (*github.Branch, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranch", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*github.Branch)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBranch indicates an expected call of GetBranch.
+func (mr *MockGithubRepositoriesServiceMockRecorder) GetBranch(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranch", reflect.TypeOf((*MockGithubRepositoriesService)(nil).GetBranch), arg0, arg1, arg2, arg3)
+}
+
+// GetCombinedStatus mocks base method.
+func (m *MockGithubRepositoriesService) GetCombinedStatus(arg0 context.Context, arg1, arg2, arg3 string, arg4 *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCombinedStatus", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*github.CombinedStatus)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCombinedStatus indicates an expected call of GetCombinedStatus.
+func (mr *MockGithubRepositoriesServiceMockRecorder) GetCombinedStatus(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCombinedStatus", reflect.TypeOf((*MockGithubRepositoriesService)(nil).GetCombinedStatus), arg0, arg1, arg2, arg3, arg4)
+}
+
 // GetCommit mocks base method.
 func (m *MockGithubRepositoriesService) GetCommit(arg0 context.Context, arg1, arg2, arg3 string) (*github.RepositoryCommit, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -116,6 +195,22 @@ func (mr *MockGithubRepositoriesServiceMockRecorder) GetCommit(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommit", reflect.TypeOf((*MockGithubRepositoriesService)(nil).GetCommit), arg0, arg1, arg2, arg3)
 }
 
+// GetLatestRelease mocks base method.
+func (m *MockGithubRepositoriesService) GetLatestRelease(arg0 context.Context, arg1, arg2 string) (*github.RepositoryRelease, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestRelease", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*github.RepositoryRelease)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLatestRelease indicates an expected call of GetLatestRelease.
+func (mr *MockGithubRepositoriesServiceMockRecorder) GetLatestRelease(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestRelease", reflect.TypeOf((*MockGithubRepositoriesService)(nil).GetLatestRelease), arg0, arg1, arg2)
+}
+
 // GetReleaseByTag mocks base method.
 func (m *MockGithubRepositoriesService) GetReleaseByTag(arg0 context.Context, arg1, arg2, arg3 string) (*github.RepositoryRelease, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +227,22 @@ func (mr *MockGithubRepositoriesServiceMockRecorder) GetReleaseByTag(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReleaseByTag", reflect.TypeOf((*MockGithubRepositoriesService)(nil).GetReleaseByTag), arg0, arg1, arg2, arg3)
 }
 
+// ListBranches mocks base method.
+func (m *MockGithubRepositoriesService) ListBranches(arg0 context.Context, arg1, arg2 string, arg3 *github.ListOptions) ([]*github.Branch, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBranches", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*github.Branch)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListBranches indicates an expected call of ListBranches.
+func (mr *MockGithubRepositoriesServiceMockRecorder) ListBranches(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBranches", reflect.TypeOf((*MockGithubRepositoriesService)(nil).ListBranches), arg0, arg1, arg2, arg3)
+}
+
 // ListReleaseAssets mocks base method.
 func (m *MockGithubRepositoriesService) ListReleaseAssets(arg0 context.Context, arg1, arg2 string, arg3 int64, arg4 *github.ListOptions) ([]*github.ReleaseAsset, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -148,6 +259,22 @@ func (mr *MockGithubRepositoriesServiceMockRecorder) ListReleaseAssets(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleaseAssets", reflect.TypeOf((*MockGithubRepositoriesService)(nil).ListReleaseAssets), arg0, arg1, arg2, arg3, arg4)
 }
 
+// ListReleases mocks base method.
+func (m *MockGithubRepositoriesService) ListReleases(arg0 context.Context, arg1, arg2 string, arg3 *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReleases", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*github.RepositoryRelease)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListReleases indicates an expected call of ListReleases.
+func (mr *MockGithubRepositoriesServiceMockRecorder) ListReleases(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleases", reflect.TypeOf((*MockGithubRepositoriesService)(nil).ListReleases), arg0, arg1, arg2, arg3)
+}
+
 // ListTags mocks base method.
 func (m *MockGithubRepositoriesService) ListTags(arg0 context.Context, arg1, arg2 string, arg3 *github.ListOptions) ([]*github.RepositoryTag, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -35,18 +35,18 @@ func (m *MockGithubSearchService) EXPECT() *MockGithubSearchServiceMockRecorder
 	return m.recorder
 }
 
-// Repositories mocks base method.
-func (m *MockGithubSearchService) Repositories(arg0 context.Context, arg1 string, arg2 *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+// Issues mocks base method.
+func (m *MockGithubSearchService) Issues(arg0 context.Context, arg1 string, arg2 *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Repositories", arg0, arg1, arg2)
-	ret0, _ := ret[0].(*github.RepositoriesSearchResult)
+	ret := m.ctrl.Call(m, "Issues", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*github.IssuesSearchResult)
 	ret1, _ := ret[1].(*github.Response)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
-// Repositories indicates an expected call of Repositories.
-func (mr *MockGithubSearchServiceMockRecorder) Repositories(arg0, arg1, arg2 interface{}) *gomock.Call {
+// Issues indicates an expected call of Issues.
+func (mr *MockGithubSearchServiceMockRecorder) Issues(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repositories", reflect.TypeOf((*MockGithubSearchService)(nil).Repositories), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issues", reflect.TypeOf((*MockGithubSearchService)(nil).Issues), arg0, arg1, arg2)
 }
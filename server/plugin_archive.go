@@ -0,0 +1,316 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// walkPluginArchive calls fn for every entry of the given plugin.tar.gz, in archive order.
+// Entry names are cleaned, and entries escaping the archive root are rejected.
+func walkPluginArchive(pluginFilePath string, fn func(name string, header *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(pluginFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open archive %s", pluginFilePath)
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decompress %s", pluginFilePath)
+	}
+	defer gzr.Close()
+
+	tarReader := tar.NewReader(gzr)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", pluginFilePath)
+		}
+
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if !filepath.IsLocal(name) {
+			return errors.Errorf("archive entry %q escapes the archive root", header.Name)
+		}
+
+		// Symbolic links are relative to the entry, hard links to the archive root
+		linkTarget := header.Linkname
+		if header.Typeflag == tar.TypeSymlink && !path.IsAbs(linkTarget) {
+			linkTarget = path.Join(path.Dir(name), linkTarget)
+		}
+		if (header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink) && !filepath.IsLocal(linkTarget) {
+			return errors.Errorf("archive entry %q links outside of the archive root", header.Name)
+		}
+
+		if err := fn(name, header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// pluginArchiveRoot returns the directory containing the plugin given all entry names: if the
+// root of the plugin bundle consists of exactly one directory, the plugin is assumed to be
+// contained therein. Otherwise the root directory is expected to contain the plugin.
+func pluginArchiveRoot(names []string) string {
+	var root string
+	isDir := false
+	for _, name := range names {
+		if name == "." {
+			continue
+		}
+
+		first, rest, _ := strings.Cut(name, "/")
+		if root != "" && first != root {
+			return ""
+		}
+		root = first
+		isDir = isDir || rest != ""
+	}
+
+	if !isDir {
+		return ""
+	}
+
+	return root
+}
+
+// readPluginManifest reads the manifest of the given plugin.tar.gz without extracting it.
+func readPluginManifest(pluginFilePath string) (*model.Manifest, error) {
+	var names []string
+	manifests := make(map[string][]byte)
+	err := walkPluginArchive(pluginFilePath, func(name string, header *tar.Header, r io.Reader) error {
+		names = append(names, name)
+
+		switch path.Base(name) {
+		case "plugin.json", "plugin.yml", "plugin.yaml":
+			if header.Typeflag != tar.TypeReg || strings.Count(name, "/") > 1 {
+				return nil
+			}
+
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", name)
+			}
+			manifests[name] = data
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root := pluginArchiveRoot(names)
+
+	// Same precedence as model.FindManifest
+	for _, name := range []string{"plugin.yml", "plugin.yaml"} {
+		if data, ok := manifests[path.Join(root, name)]; ok {
+			var manifest model.Manifest
+			if err := yaml.Unmarshal(data, &manifest); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s", name)
+			}
+			manifest.Id = strings.ToLower(manifest.Id)
+			return &manifest, nil
+		}
+	}
+
+	data, ok := manifests[path.Join(root, "plugin.json")]
+	if !ok {
+		return nil, errors.New("plugin manifest not found")
+	}
+
+	var manifest model.Manifest
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse plugin.json")
+	}
+	manifest.Id = strings.ToLower(manifest.Id)
+
+	return &manifest, nil
+}
+
+// readPluginArchiveFile reads the file at the given path, relative to the plugin root, from the
+// given plugin.tar.gz.
+func readPluginArchiveFile(pluginFilePath, name string) ([]byte, error) {
+	var names []string
+	err := walkPluginArchive(pluginFilePath, func(name string, _ *tar.Header, _ io.Reader) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target := path.Join(pluginArchiveRoot(names), path.Clean(name))
+
+	var data []byte
+	found := false
+	err = walkPluginArchive(pluginFilePath, func(name string, header *tar.Header, r io.Reader) error {
+		if name != target || header.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		found = true
+		data, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.Errorf("%s not found in plugin", name)
+	}
+
+	return data, nil
+}
+
+// reproducibleModTime is the modification time of every entry of repackaged plugins.
+var reproducibleModTime = time.Unix(0, 0).UTC()
+
+type archiveEntry struct {
+	header      *tar.Header
+	contentPath string // Spooled content of regular files
+}
+
+// repackPlugin copies the given plugin.tar.gz into a new archive at destPath, at best gzip
+// compression, leaving out the entries for which keep returns false. The archive is reproducible:
+// entries are sorted by name, only their type, name, link name and permissions are kept, and the
+// gzip header is fixed, so the same input always yields the same bytes.
+func repackPlugin(pluginFilePath, destPath string, keep func(name string) bool) (err error) {
+	spoolDir, err := os.MkdirTemp("", "platform-plugin-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(spoolDir)
+
+	var entries []*archiveEntry
+	err = walkPluginArchive(pluginFilePath, func(name string, header *tar.Header, r io.Reader) error {
+		if name == "." || !keep(name) {
+			return nil
+		}
+
+		entry := &archiveEntry{header: normalizeArchiveHeader(name, header)}
+		if header.Typeflag == tar.TypeReg {
+			entry.contentPath = filepath.Join(spoolDir, strconv.Itoa(len(entries)))
+			if err := spoolArchiveEntry(entry.contentPath, r); err != nil {
+				return errors.Wrapf(err, "failed to read %s", name)
+			}
+		}
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].header.Name < entries[j].header.Name
+	})
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", destPath)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = errors.Wrapf(cerr, "failed to close %s", destPath)
+		}
+	}()
+
+	gzw, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gzip writer")
+	}
+	gzw.Header = gzip.Header{OS: 255} // Unknown OS, no name nor modification time
+
+	tarWriter := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		if err := writeArchiveEntry(tarWriter, entry); err != nil {
+			return errors.Wrapf(err, "failed to write %s", entry.header.Name)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return errors.Wrap(err, "failed to close tar writer")
+	}
+
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close gzip writer")
+	}
+
+	return nil
+}
+
+// normalizeArchiveHeader returns a header for the entry stripped of everything depending on the
+// machine or the time the plugin was built.
+func normalizeArchiveHeader(name string, header *tar.Header) *tar.Header {
+	normalized := &tar.Header{
+		Typeflag: header.Typeflag,
+		Name:     name,
+		Linkname: header.Linkname,
+		Size:     header.Size,
+		Mode:     header.Mode & 0777,
+		ModTime:  reproducibleModTime,
+	}
+	if header.Typeflag == tar.TypeDir {
+		normalized.Name += "/"
+	}
+
+	return normalized
+}
+
+func spoolArchiveEntry(contentPath string, r io.Reader) error {
+	f, err := os.Create(contentPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeArchiveEntry(tarWriter *tar.Writer, entry *archiveEntry) error {
+	if err := tarWriter.WriteHeader(entry.header); err != nil {
+		return err
+	}
+
+	if entry.contentPath == "" {
+		return nil
+	}
+
+	f, err := os.Open(entry.contentPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tarWriter, f)
+	return err
+}
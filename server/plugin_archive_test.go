@@ -0,0 +1,244 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testArchiveEntry struct {
+	Name     string
+	Body     string
+	Mode     int64
+	Typeflag byte
+	Linkname string
+	Uid      int
+	ModTime  time.Time
+}
+
+// writeTestArchive writes a plugin.tar.gz with the given entries.
+func writeTestArchive(t *testing.T, filePath string, entries []testArchiveEntry) {
+	t.Helper()
+
+	f, err := os.Create(filePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.Name,
+			Mode:     entry.Mode,
+			Typeflag: entry.Typeflag,
+			Linkname: entry.Linkname,
+			Size:     int64(len(entry.Body)),
+			Uid:      entry.Uid,
+			ModTime:  entry.ModTime,
+		}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write([]byte(entry.Body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+}
+
+func TestWalkPluginArchive(t *testing.T) {
+	noop := func(string, *tar.Header, io.Reader) error { return nil }
+
+	for name, entry := range map[string]testArchiveEntry{
+		"parent directory":   {Name: "../evil"},
+		"nested parent":      {Name: "plugin/../../evil"},
+		"absolute path":      {Name: "/etc/passwd"},
+		"symlink outside":    {Name: "plugin/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"},
+		"hard link outside":  {Name: "plugin/link", Typeflag: tar.TypeLink, Linkname: "../etc/passwd"},
+		"absolute link name": {Name: "plugin/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+			writeTestArchive(t, archivePath, []testArchiveEntry{{Name: "plugin/plugin.json", Body: "{}"}, entry})
+
+			require.Error(t, walkPluginArchive(archivePath, noop))
+		})
+	}
+
+	t.Run("cleaned names", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "./plugin/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "./plugin/plugin.json", Body: "{}"},
+			{Name: "./plugin/server/dist/link", Typeflag: tar.TypeSymlink, Linkname: "plugin-linux-amd64"},
+		})
+
+		var names []string
+		err := walkPluginArchive(archivePath, func(name string, _ *tar.Header, _ io.Reader) error {
+			names = append(names, name)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{".", "plugin", "plugin/plugin.json", "plugin/server/dist/link"}, names)
+	})
+}
+
+func TestPluginArchiveRoot(t *testing.T) {
+	require.Equal(t, "plugin", pluginArchiveRoot([]string{".", "plugin", "plugin/plugin.json"}))
+	require.Equal(t, "plugin", pluginArchiveRoot([]string{"plugin/plugin.json"}))
+	require.Equal(t, "", pluginArchiveRoot([]string{"plugin.json", "server/dist/plugin-linux-amd64"}))
+	require.Equal(t, "", pluginArchiveRoot([]string{"plugin.json"}))
+	require.Equal(t, "", pluginArchiveRoot(nil))
+}
+
+func TestReadPluginManifest(t *testing.T) {
+	t.Run("plugin.json in a directory", func(t *testing.T) {
+		manifest, err := readPluginManifest(filepath.Join("test", "mattermost-plugin-demo-v0.4.1.tar.gz"))
+		require.NoError(t, err)
+		require.Equal(t, "com.mattermost.demo-plugin", manifest.Id)
+	})
+
+	t.Run("plugin.json at the root", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin.json", Body: `{"id": "Com.Example.Root", "version": "1.0.0"}`},
+			{Name: "server/dist/plugin-linux-amd64", Body: "binary", Mode: 0755},
+		})
+
+		manifest, err := readPluginManifest(archivePath)
+		require.NoError(t, err)
+		require.Equal(t, "com.example.root", manifest.Id)
+	})
+
+	t.Run("plugin.yml takes precedence", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.json"}`},
+			{Name: "plugin/plugin.yml", Body: "id: com.example.yaml\nserver:\n  executables:\n    linux-amd64: server/dist/plugin-linux-amd64\n"},
+		})
+
+		manifest, err := readPluginManifest(archivePath)
+		require.NoError(t, err)
+		require.Equal(t, "com.example.yaml", manifest.Id)
+		require.Equal(t, map[string]string{"linux-amd64": "server/dist/plugin-linux-amd64"}, manifest.Server.Executables)
+	})
+
+	t.Run("nested manifests are ignored", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/webapp/plugin.json", Body: `{"id": "com.example.webapp"}`},
+		})
+
+		_, err := readPluginManifest(archivePath)
+		require.EqualError(t, err, "plugin manifest not found")
+	})
+}
+
+func TestRepackPlugin(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "plugin.tar.gz")
+	writeTestArchive(t, archivePath, []testArchiveEntry{
+		{Name: "plugin/", Typeflag: tar.TypeDir, Mode: 0750},
+		{Name: "plugin/plugin.json", Body: "{}", Mode: 0640},
+		{Name: "plugin/server/dist/plugin-linux-amd64", Body: "linux", Mode: 0755},
+		{Name: "plugin/server/dist/plugin-darwin-amd64", Body: "darwin", Mode: 0755},
+	})
+
+	repackedPath := filepath.Join(dir, "repacked.tar.gz")
+	err := repackPlugin(archivePath, repackedPath, func(name string) bool {
+		return name != "plugin/server/dist/plugin-darwin-amd64"
+	})
+	require.NoError(t, err)
+
+	modes := map[string]int64{}
+	bodies := map[string]string{}
+	err = walkPluginArchive(repackedPath, func(name string, header *tar.Header, r io.Reader) error {
+		modes[name] = header.Mode
+		data, err := io.ReadAll(r)
+		bodies[name] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{
+		"plugin":                                0750,
+		"plugin/plugin.json":                    0640,
+		"plugin/server/dist/plugin-linux-amd64": 0755,
+	}, modes)
+	require.Equal(t, "linux", bodies["plugin/server/dist/plugin-linux-amd64"])
+
+	// The XFL byte of the gzip header flags the best compression
+	data, err := os.ReadFile(repackedPath)
+	require.NoError(t, err)
+	require.Equal(t, byte(2), data[8])
+}
+
+func TestRepackPluginIsReproducible(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	archivePath1 := filepath.Join(dir, "plugin1.tar.gz")
+	writeTestArchive(t, archivePath1, []testArchiveEntry{
+		{Name: "plugin/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: now},
+		{Name: "plugin/plugin.json", Body: "{}", Uid: 501, ModTime: now},
+		{Name: "plugin/server/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: now},
+		{Name: "plugin/server/dist/plugin-linux-amd64", Body: "linux", Mode: 0755, Uid: 501, ModTime: now},
+	})
+
+	// Same content, built at another time, by another user, in another order
+	archivePath2 := filepath.Join(dir, "plugin2.tar.gz")
+	writeTestArchive(t, archivePath2, []testArchiveEntry{
+		{Name: "./plugin/server/dist/plugin-linux-amd64", Body: "linux", Mode: 0755, Uid: 1000, ModTime: now.Add(time.Hour)},
+		{Name: "./plugin/server/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "./plugin/plugin.json", Body: "{}", Uid: 1000},
+		{Name: "./plugin/", Typeflag: tar.TypeDir, Mode: 0755},
+	})
+
+	keepAll := func(string) bool { return true }
+	repackedPath1 := filepath.Join(dir, "repacked1.tar.gz")
+	require.NoError(t, repackPlugin(archivePath1, repackedPath1, keepAll))
+	repackedPath2 := filepath.Join(dir, "repacked2.tar.gz")
+	require.NoError(t, repackPlugin(archivePath2, repackedPath2, keepAll))
+
+	data1, err := os.ReadFile(repackedPath1)
+	require.NoError(t, err)
+	data2, err := os.ReadFile(repackedPath2)
+	require.NoError(t, err)
+	require.Equal(t, data1, data2)
+
+	var names []string
+	err = walkPluginArchive(repackedPath1, func(name string, header *tar.Header, _ io.Reader) error {
+		names = append(names, header.Name)
+		require.Equal(t, 0, header.Uid)
+		require.Equal(t, "", header.Uname)
+		require.True(t, header.ModTime.Equal(reproducibleModTime))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"plugin/", "plugin/plugin.json", "plugin/server/", "plugin/server/dist/plugin-linux-amd64"}, names)
+
+	t.Run("repacking a repacked plugin is stable", func(t *testing.T) {
+		repackedPath3 := filepath.Join(dir, "repacked3.tar.gz")
+		require.NoError(t, repackPlugin(repackedPath1, repackedPath3, keepAll))
+
+		data3, err := os.ReadFile(repackedPath3)
+		require.NoError(t, err)
+		require.Equal(t, data1, data3)
+	})
+}
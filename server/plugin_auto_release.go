@@ -0,0 +1,81 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// isAutoReleaseRepository returns true if the published releases of the given repository of
+// GithubOrg are signed automatically.
+func isAutoReleaseRepository(cfg *MatterbuildConfig, owner, repositoryName string) bool {
+	if !strings.EqualFold(owner, cfg.GithubOrg) {
+		return false
+	}
+
+	for _, repo := range cfg.PluginAutoReleaseRepositories {
+		if strings.EqualFold(repo, repositoryName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// autoReleasePlugin cuts the plugin release of the given release event in the background, with
+// the same checks as the cutplugin command, and reports to PluginAutoReleaseWebhookURL. Returns
+// an empty string if the cut started, or why it was skipped.
+func autoReleasePlugin(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, event *github.ReleaseEvent) string {
+	owner := event.GetRepo().GetOwner().GetLogin()
+	repositoryName := event.GetRepo().GetName()
+	release := event.GetRelease()
+	tag := release.GetTagName()
+
+	if event.GetAction() != "published" {
+		return fmt.Sprintf("release %s", event.GetAction())
+	}
+	if !isAutoReleaseRepository(cfg, owner, repositoryName) {
+		return "repository not enabled for automatic releases"
+	}
+	if release.GetDraft() {
+		return "draft release"
+	}
+	if err := validatePluginTag(tag); err != nil {
+		return err.Error()
+	}
+
+	for _, asset := range release.Assets {
+		if strings.HasSuffix(asset.GetName(), ".tar.gz.sig") {
+			return fmt.Sprintf("already signed, found %s", asset.GetName())
+		}
+	}
+
+	if err := checkRepo(ctx, cfg, client, owner, repositoryName); err != nil {
+		return err.Error()
+	}
+
+	unlock, ok := lockPluginRelease(owner, repositoryName, tag)
+	if !ok {
+		return "already being released"
+	}
+
+	sender := event.GetSender().GetLogin()
+	msg := fmt.Sprintf("%s published %s of `%s` on GitHub. Waiting for the artifacts to sign and publish.\nWill report back when the process completes.", sender, tag, repositoryName)
+	if err := PostExtraMessages(cfg.PluginAutoReleaseWebhookURL, GenerateEnrichedSlashResponse("Plugin Release Process", msg, "#0060aa", model.CommandResponseTypeInChannel)); err != nil {
+		LogError("failed to post automatic plugin release through PostExtraMessages err=%s", err.Error())
+	}
+
+	go func() {
+		defer unlock()
+		result, err := cutPlugin(ctx, cfg, client, owner, repositoryName, tag, "", false, false)
+		reportCutPlugin(ctx, client, cfg.PluginAutoReleaseWebhookURL, sender, repositoryName, tag, "", nil, result, err)
+	}()
+
+	return ""
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestIsAutoReleaseRepository(t *testing.T) {
+	cfg := &MatterbuildConfig{
+		GithubOrg:                     "mattermost",
+		PluginAutoReleaseRepositories: []string{"mattermost-plugin-demo"},
+	}
+
+	require.True(t, isAutoReleaseRepository(cfg, "mattermost", "mattermost-plugin-demo"))
+	require.True(t, isAutoReleaseRepository(cfg, "Mattermost", "Mattermost-Plugin-Demo"))
+	require.False(t, isAutoReleaseRepository(cfg, "mattermost", "mattermost-plugin-jira"))
+	require.False(t, isAutoReleaseRepository(cfg, "someone", "mattermost-plugin-demo"))
+}
+
+func TestAutoReleasePlugin(t *testing.T) {
+	ctx := context.Background()
+
+	posts := make(chan string, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posts <- string(body)
+	}))
+	defer webhookServer.Close()
+
+	cfg := &MatterbuildConfig{
+		GithubOrg:                     "mattermost",
+		PluginAutoReleaseRepositories: []string{"mattermost-plugin-demo"},
+		PluginAutoReleaseWebhookURL:   webhookServer.URL,
+		PluginOperationsDir:           t.TempDir(),
+	}
+	Cfg = cfg
+
+	newEvent := func(action, repo, tag string) *github.ReleaseEvent {
+		return &github.ReleaseEvent{
+			Action:  github.String(action),
+			Release: &github.RepositoryRelease{TagName: github.String(tag)},
+			Repo:    &github.Repository{Name: github.String(repo), Owner: &github.User{Login: github.String("mattermost")}},
+			Sender:  &github.User{Login: github.String("someone")},
+		}
+	}
+
+	repository := &github.Repository{
+		Name:     github.String("mattermost-plugin-demo"),
+		FullName: github.String("mattermost/mattermost-plugin-demo"),
+		Owner:    &github.User{Login: github.String("mattermost")},
+	}
+	newClient := func(t *testing.T, repository *github.Repository) (*GithubClient, *mocks.MockGithubRepositoriesService) {
+		repoMock := mocks.NewMockGithubRepositoriesService(gomock.NewController(t))
+		repoMock.EXPECT().Get(gomock.Any(), "mattermost", "mattermost-plugin-demo").Return(repository, nil, nil)
+		return &GithubClient{Repositories: repoMock}, repoMock
+	}
+
+	t.Run("skipped releases", func(t *testing.T) {
+		require.Equal(t, "release created", autoReleasePlugin(ctx, cfg, nil, newEvent("created", "mattermost-plugin-demo", "v1.0.0")))
+		require.Equal(t, "repository not enabled for automatic releases", autoReleasePlugin(ctx, cfg, nil, newEvent("published", "mattermost-plugin-jira", "v1.0.0")))
+		require.Equal(t, "Tag must start with leading 'v'", autoReleasePlugin(ctx, cfg, nil, newEvent("published", "mattermost-plugin-demo", "1.0.0")))
+
+		event := newEvent("published", "mattermost-plugin-demo", "v1.0.0")
+		event.Release.Draft = github.Bool(true)
+		require.Equal(t, "draft release", autoReleasePlugin(ctx, cfg, nil, event))
+
+		event = newEvent("published", "mattermost-plugin-demo", "v1.0.0")
+		event.Release.Assets = []github.ReleaseAsset{{Name: github.String("mattermost-plugin-demo-v1.0.0.tar.gz.sig")}}
+		require.Equal(t, "already signed, found mattermost-plugin-demo-v1.0.0.tar.gz.sig", autoReleasePlugin(ctx, cfg, nil, event))
+
+		unlock, ok := lockPluginRelease("mattermost", "mattermost-plugin-demo", "v1.0.0")
+		require.True(t, ok)
+		defer unlock()
+		client, _ := newClient(t, repository)
+		require.Equal(t, "already being released", autoReleasePlugin(ctx, cfg, client, newEvent("published", "mattermost-plugin-demo", "v1.0.0")))
+		require.Empty(t, posts)
+	})
+
+	t.Run("repositories rejected as by cutplugin", func(t *testing.T) {
+		deniedCfg := *cfg
+		deniedCfg.PluginRepositoriesDenied = []string{"mattermost-plugin-*"}
+		require.Equal(t, "repository mattermost-plugin-demo is not allowed to be released by matterbuild", autoReleasePlugin(ctx, &deniedCfg, &GithubClient{}, newEvent("published", "mattermost-plugin-demo", "v1.0.0")))
+
+		archived := *repository
+		archived.Archived = github.Bool(true)
+		client, _ := newClient(t, &archived)
+		require.Equal(t, "repository mattermost-plugin-demo is archived", autoReleasePlugin(ctx, cfg, client, newEvent("published", "mattermost-plugin-demo", "v1.0.0")))
+		require.Empty(t, posts)
+	})
+
+	t.Run("failed release is reported", func(t *testing.T) {
+		client, repoMock := newClient(t, repository)
+		repoMock.EXPECT().GetReleaseByTag(gomock.Any(), "mattermost", "mattermost-plugin-demo", "v1.1.0").Return(nil, nil, errors.New("boom"))
+
+		require.Empty(t, autoReleasePlugin(ctx, cfg, client, newEvent("published", "mattermost-plugin-demo", "v1.1.0")))
+
+		select {
+		case post := <-posts:
+			require.Contains(t, post, "someone published v1.1.0 of `mattermost-plugin-demo` on GitHub")
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "start message not posted")
+		}
+
+		select {
+		case post := <-posts:
+			require.Contains(t, post, "failed at stage downloaded: failed to get plugin asset: failed to get release by tag: boom")
+			require.Contains(t, post, "/matterbuild cutplugin --resume")
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "error message not posted")
+		}
+
+		// The release can be cut again once done
+		require.Eventually(t, func() bool {
+			unlock, ok := lockPluginRelease("mattermost", "mattermost-plugin-demo", "v1.1.0")
+			if ok {
+				unlock()
+			}
+			return ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
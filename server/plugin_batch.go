@@ -0,0 +1,175 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const defaultPluginBatchParallelism = 3
+
+// Outcomes of the plugin releases of a batch.
+const (
+	pluginBatchReleased = "released"
+	pluginBatchSkipped  = "skipped"
+	pluginBatchFailed   = "failed"
+)
+
+// pluginBatchItem is the release of a plugin cut by cutplugins.
+type pluginBatchItem struct {
+	Repository string
+	Tag        string
+
+	Status      string
+	Details     string
+	OperationID string // Set when the cut failed and can be resumed
+}
+
+// parsePluginBatch returns the plugin releases of the given repo@tag arguments. An argument
+// without @ is the name of a bundle of releases configured in PluginBundles.
+func parsePluginBatch(cfg *MatterbuildConfig, args []string) ([]*pluginBatchItem, error) {
+	var specs []string
+	for _, arg := range args {
+		if strings.Contains(arg, "@") {
+			specs = append(specs, arg)
+			continue
+		}
+
+		bundle, ok := cfg.PluginBundles[arg]
+		if !ok {
+			return nil, errors.Errorf("unknown plugin bundle %q, expected repo@tag or one of the configured bundles", arg)
+		}
+		specs = append(specs, bundle...)
+	}
+
+	if len(specs) == 0 {
+		return nil, errors.New("no plugin releases given")
+	}
+
+	var items []*pluginBatchItem
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		parts := strings.Split(spec, "@")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid plugin release %q, expected repo@tag", spec)
+		}
+		repo, tag := parts[0], parts[1]
+
+		if err := validatePluginTag(tag); err != nil {
+			return nil, errors.Wrapf(err, "invalid plugin release %q", spec)
+		}
+
+		if seen[strings.ToLower(repo)] {
+			return nil, errors.Errorf("%s is listed more than once", repo)
+		}
+		seen[strings.ToLower(repo)] = true
+
+		items = append(items, &pluginBatchItem{Repository: repo, Tag: tag})
+	}
+
+	return items, nil
+}
+
+// cutPluginBatch creates the tags and cuts the releases of the given plugins, at most
+// PluginBatchParallelism at a time, for the given user and slash command. The outcome of each is
+// recorded in its item.
+func cutPluginBatch(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner string, items []*pluginBatchItem, username, command string, force, preRelease bool) {
+	parallelism := cfg.PluginBatchParallelism
+	if parallelism <= 0 {
+		parallelism = defaultPluginBatchParallelism
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for _, item := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(item *pluginBatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			cutPluginBatchItem(ctx, cfg, client, owner, item, username, command, force, preRelease)
+			LogInfo("Plugin release %s %s of the batch %s", item.Repository, item.Tag, item.Status)
+		}(item)
+	}
+	wg.Wait()
+}
+
+func cutPluginBatchItem(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner string, item *pluginBatchItem, username, command string, force, preRelease bool) {
+	unlock, ok := lockPluginRelease(owner, item.Repository, item.Tag)
+	if !ok {
+		item.Status, item.Details = pluginBatchSkipped, "already being released"
+		return
+	}
+	defer unlock()
+
+	if err := checkRepo(ctx, cfg, client, owner, item.Repository); err != nil {
+		item.Status, item.Details = pluginBatchFailed, err.Error()
+		return
+	}
+
+	request := &pluginTagRequest{Repository: item.Repository, Tag: item.Tag, Username: username, Command: command}
+	if err := createTag(ctx, cfg, client, owner, "", request); errors.Is(err, ErrTagExists) {
+		if !force {
+			item.Status, item.Details = pluginBatchSkipped, "tag already exists, use --force to regenerate the artifacts"
+			return
+		}
+	} else if err != nil {
+		item.Status, item.Details = pluginBatchFailed, err.Error()
+		return
+	}
+
+	if _, err := cutPlugin(ctx, cfg, client, owner, item.Repository, item.Tag, "", preRelease, false); err != nil {
+		item.Status, item.Details = pluginBatchFailed, err.Error()
+
+		var opErr *pluginOperationError
+		if errors.As(err, &opErr) {
+			item.OperationID = opErr.ID
+		}
+		return
+	}
+
+	item.Status = pluginBatchReleased
+}
+
+// pluginBatchSummary returns the outcome of the releases of a batch as a markdown table.
+func pluginBatchSummary(items []*pluginBatchItem) string {
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Status]++
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d released, %d skipped, %d failed.\n\n", counts[pluginBatchReleased], counts[pluginBatchSkipped], counts[pluginBatchFailed])
+	sb.WriteString("| Plugin | Tag | Status | Details |\n")
+	sb.WriteString("|:--|:--|:--|:--|\n")
+
+	for _, item := range items {
+		status := item.Status
+		switch item.Status {
+		case pluginBatchReleased:
+			status = ":white_check_mark: " + status
+		case pluginBatchSkipped:
+			status = ":warning: " + status
+		case pluginBatchFailed:
+			status = ":x: " + status
+		}
+
+		details := item.Details
+		if item.OperationID != "" {
+			details += fmt.Sprintf(" Resume with `/matterbuild cutplugin --resume %s`.", item.OperationID)
+		}
+		details = strings.NewReplacer("|", "\\|", "\n", " ").Replace(details)
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", item.Repository, item.Tag, status, details)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestParsePluginBatch(t *testing.T) {
+	cfg := &MatterbuildConfig{
+		PluginBundles: map[string][]string{
+			"v9.0": {"mattermost-plugin-jira@v4.0.0", "mattermost-plugin-github@v2.1.0"},
+		},
+	}
+
+	t.Run("releases and bundles", func(t *testing.T) {
+		items, err := parsePluginBatch(cfg, []string{"mattermost-plugin-demo@v0.4.1", "v9.0"})
+		require.NoError(t, err)
+		require.Equal(t, []*pluginBatchItem{
+			{Repository: "mattermost-plugin-demo", Tag: "v0.4.1"},
+			{Repository: "mattermost-plugin-jira", Tag: "v4.0.0"},
+			{Repository: "mattermost-plugin-github", Tag: "v2.1.0"},
+		}, items)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parsePluginBatch(cfg, nil)
+		require.EqualError(t, err, "no plugin releases given")
+
+		_, err = parsePluginBatch(cfg, []string{"v8.0"})
+		require.EqualError(t, err, `unknown plugin bundle "v8.0", expected repo@tag or one of the configured bundles`)
+
+		_, err = parsePluginBatch(cfg, []string{"@v1.0.0"})
+		require.EqualError(t, err, `invalid plugin release "@v1.0.0", expected repo@tag`)
+
+		_, err = parsePluginBatch(cfg, []string{"mattermost-plugin-demo@1.0.0"})
+		require.EqualError(t, err, `invalid plugin release "mattermost-plugin-demo@1.0.0": Tag must start with leading 'v'`)
+
+		_, err = parsePluginBatch(cfg, []string{"v9.0", "mattermost-plugin-jira@v4.0.1"})
+		require.EqualError(t, err, "mattermost-plugin-jira is listed more than once")
+	})
+}
+
+func TestCutPluginBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	owner := "mattermost"
+	cfg := &MatterbuildConfig{PluginOperationsDir: t.TempDir(), PluginBatchParallelism: 2}
+
+	gitMock := mocks.NewMockGithubGitService(ctrl)
+	gitMock.EXPECT().GetRefs(gomock.Any(), owner, "mattermost-plugin-tagged", "tags/v1.0.0").Return([]*github.Reference{{Ref: github.String("refs/tags/v1.0.0")}}, nil, nil)
+	gitMock.EXPECT().GetRefs(gomock.Any(), owner, "mattermost-plugin-demo", "tags/v0.4.1").Return(nil, nil, nil)
+	gitMock.EXPECT().GetRef(gomock.Any(), owner, "mattermost-plugin-demo", "heads/master").Return(&github.Reference{Object: &github.GitObject{SHA: github.String("abc")}}, nil, nil)
+	gitMock.EXPECT().CreateTag(gomock.Any(), owner, "mattermost-plugin-demo", gomock.Any()).Return(&github.Tag{SHA: github.String("def")}, nil, nil)
+	gitMock.EXPECT().CreateRef(gomock.Any(), owner, "mattermost-plugin-demo", gomock.Any()).Return(nil, nil, nil)
+
+	repository := func(name string) *github.Repository {
+		return &github.Repository{Name: github.String(name), Owner: &github.User{Login: github.String(owner)}}
+	}
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+	repoMock.EXPECT().Get(gomock.Any(), owner, "mattermost-plugin-tagged").Return(repository("mattermost-plugin-tagged"), nil, nil)
+	repoMock.EXPECT().Get(gomock.Any(), owner, "mattermost-plugin-missing").Return(nil, nil, notFound)
+	repoMock.EXPECT().Get(gomock.Any(), owner, "mattermost-plugin-demo").Return(repository("mattermost-plugin-demo"), nil, nil).Times(2)
+	repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, "mattermost-plugin-demo", "v0.4.1").Return(nil, nil, errors.New("boom"))
+
+	client := &GithubClient{Repositories: repoMock, Git: gitMock}
+
+	unlock, ok := lockPluginRelease(owner, "mattermost-plugin-busy", "v2.0.0")
+	require.True(t, ok)
+	defer unlock()
+
+	items := []*pluginBatchItem{
+		{Repository: "mattermost-plugin-tagged", Tag: "v1.0.0"},
+		{Repository: "mattermost-plugin-missing", Tag: "v1.0.0"},
+		{Repository: "mattermost-plugin-busy", Tag: "v2.0.0"},
+		{Repository: "mattermost-plugin-demo", Tag: "v0.4.1"},
+	}
+	cutPluginBatch(ctx, cfg, client, owner, items, "someone", "/matterbuild cutplugins", false, false)
+
+	require.Equal(t, pluginBatchSkipped, items[0].Status)
+	require.Equal(t, "tag already exists, use --force to regenerate the artifacts", items[0].Details)
+	require.Equal(t, pluginBatchFailed, items[1].Status)
+	require.Contains(t, items[1].Details, "not part of the org or does not exist")
+	require.Equal(t, pluginBatchSkipped, items[2].Status)
+	require.Equal(t, "already being released", items[2].Details)
+	require.Equal(t, pluginBatchFailed, items[3].Status)
+	require.Regexp(t, pluginOperationIDPattern, items[3].OperationID)
+
+	summary := pluginBatchSummary(items)
+	require.Contains(t, summary, "0 released, 2 skipped, 2 failed.")
+	require.Contains(t, summary, "| mattermost-plugin-busy | v2.0.0 | :warning: skipped | already being released |")
+	require.Contains(t, summary, "Resume with `/matterbuild cutplugin --resume "+items[3].OperationID+"`.")
+}
+
+func TestPluginBatchSummary(t *testing.T) {
+	summary := pluginBatchSummary([]*pluginBatchItem{
+		{Repository: "mattermost-plugin-demo", Tag: "v0.4.1", Status: pluginBatchReleased},
+		{Repository: "mattermost-plugin-jira", Tag: "v4.0.0", Status: pluginBatchFailed, Details: "a | b\nc"},
+	})
+
+	require.Equal(t, "1 released, 0 skipped, 1 failed.\n\n"+
+		"| Plugin | Tag | Status | Details |\n"+
+		"|:--|:--|:--|:--|\n"+
+		"| mattermost-plugin-demo | v0.4.1 | :white_check_mark: released |  |\n"+
+		"| mattermost-plugin-jira | v4.0.0 | :x: failed | a \\| b c |\n", summary)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pluginArtifact describes a published plugin tar.
+type pluginArtifact struct {
+	Name           string `json:"name"`
+	Platform       string `json:"platform,omitempty"` // Empty for the bundle of all platforms
+	Size           int64  `json:"size"`
+	SHA256         string `json:"sha256"`
+	Signature      string `json:"signature"`
+	SignatureKeyID string `json:"signature_key_id"`
+}
+
+// pluginReleaseManifest lists the artifacts published for a plugin release.
+type pluginReleaseManifest struct {
+	Repository  string            `json:"repository"`
+	Tag         string            `json:"tag"`
+	SourceAsset string            `json:"source_asset"` // Name of the GitHub release asset the artifacts were built from
+	Artifacts   []*pluginArtifact `json:"artifacts"`
+}
+
+// newPluginArtifact describes the plugin tar at filePath, published as name.
+func newPluginArtifact(filePath, name, platform, signatureKeyID string) (*pluginArtifact, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat %s", filePath)
+	}
+
+	checksum, err := sha256File(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pluginArtifact{
+		Name:           name,
+		Platform:       platform,
+		Size:           info.Size(),
+		SHA256:         checksum,
+		Signature:      name + ".sig",
+		SignatureKeyID: signatureKeyID,
+	}, nil
+}
+
+// checksums returns the content of the SHA256SUMS file of the release, in the sha256sum format.
+func (m *pluginReleaseManifest) checksums() string {
+	artifacts := make([]*pluginArtifact, len(m.Artifacts))
+	copy(artifacts, m.Artifacts)
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Name < artifacts[j].Name
+	})
+
+	var sb strings.Builder
+	for _, artifact := range artifacts {
+		fmt.Fprintf(&sb, "%s  %s\n", artifact.SHA256, artifact.Name)
+	}
+
+	return sb.String()
+}
+
+// writePluginReleaseManifest writes <repo>-<tag>-SHA256SUMS and <repo>-<tag>-manifest.json
+// into dir, and returns their paths.
+func writePluginReleaseManifest(dir string, manifest *pluginReleaseManifest) ([]string, error) {
+	prefix := fmt.Sprintf("%s-%s", manifest.Repository, manifest.Tag)
+
+	checksumsPath := filepath.Join(dir, prefix+"-SHA256SUMS")
+	if err := os.WriteFile(checksumsPath, []byte(manifest.checksums()), 0644); err != nil {
+		return nil, errors.Wrap(err, "failed to write checksums")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest")
+	}
+
+	manifestPath := filepath.Join(dir, prefix+"-manifest.json")
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return nil, errors.Wrap(err, "failed to write manifest")
+	}
+
+	return []string{checksumsPath, manifestPath}, nil
+}
+
+func sha256File(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open file %s", filePath)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read file %s", filePath)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePluginReleaseManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	pluginPath := filepath.Join(dir, "plugin.tar.gz")
+	require.NoError(t, os.WriteFile(pluginPath, []byte("hello"), 0644))
+
+	linux, err := newPluginArtifact(pluginPath, "myrepo-v1.0.0-linux-amd64.tar.gz", "linux-amd64", "ABCDEF")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), linux.Size)
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", linux.SHA256)
+	require.Equal(t, "myrepo-v1.0.0-linux-amd64.tar.gz.sig", linux.Signature)
+
+	bundle, err := newPluginArtifact(pluginPath, "myrepo-v1.0.0.tar.gz", "", "ABCDEF")
+	require.NoError(t, err)
+
+	manifest := &pluginReleaseManifest{
+		Repository:  "myrepo",
+		Tag:         "v1.0.0",
+		SourceAsset: "myrepo-1.0.0.tar.gz",
+		Artifacts:   []*pluginArtifact{linux, bundle},
+	}
+
+	paths, err := writePluginReleaseManifest(dir, manifest)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "myrepo-v1.0.0-SHA256SUMS"),
+		filepath.Join(dir, "myrepo-v1.0.0-manifest.json"),
+	}, paths)
+
+	sums, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  myrepo-v1.0.0-linux-amd64.tar.gz\n"+
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  myrepo-v1.0.0.tar.gz\n",
+		string(sums))
+
+	data, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	var decoded pluginReleaseManifest
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, *manifest, decoded)
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// Stages of a plugin cut, in order. A stage is only recorded once all its artifacts are in the
+// work directory, or published.
+const (
+	pluginStageDownloaded = "downloaded"
+	pluginStageSplit      = "split"
+	pluginStageSigned     = "signed"
+	pluginStageManifest   = "manifest"
+	pluginStageGithub     = "github"
+	pluginStageS3         = "s3"
+)
+
+var pluginStages = []string{
+	pluginStageDownloaded,
+	pluginStageSplit,
+	pluginStageSigned,
+	pluginStageManifest,
+	pluginStageGithub,
+	pluginStageS3,
+}
+
+// Actions recorded in the operation store.
+const (
+	pluginOperationCut       = "cut"
+	pluginOperationUnpublish = "unpublish"
+)
+
+const (
+	pluginOperationFile = "operation.json"
+
+	// pluginOperationMaxAge is how long the work directories of failed plugin cuts are kept.
+	pluginOperationMaxAge = 7 * 24 * time.Hour
+)
+
+var pluginOperationIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// runningPluginOperations holds the IDs of the plugin cuts in progress.
+var runningPluginOperations sync.Map
+
+// pluginOperation is the checkpoint of a plugin cut. It is saved in its work directory after
+// each completed stage, so a failed cut can be resumed from the first incomplete stage. It is
+// also the record of a plugin release being unpublished, listing the files removed.
+type pluginOperation struct {
+	ID         string
+	Action     string // pluginOperationCut if empty
+	Username   string // User who requested the operation, if recorded
+	Owner      string
+	Repository string
+	Tag        string
+	AssetName  string // Requested asset name, may be empty
+	PreRelease bool
+	Stage      string // Last completed stage, empty if none
+
+	Marketplace bool // Whether the Marketplace entry of the release is described once the cut completes
+
+	// Set by the completed stages
+	GithubPluginFile    string            // Name of the downloaded GitHub release asset
+	PlatformPluginFiles []string          // Names of the platform specific plugin tars
+	Notice              string            // Set when the plugin bundle is published as-is
+	Manifest            *model.Manifest   // Manifest of the plugin
+	KeyIDs              map[string]string // IDs of the keys the plugin tars are signed with, by file name
+
+	Removed []string // Files removed by an unpublish, as <location>:<name>
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	dir string
+}
+
+// pluginOperationError is returned by a plugin cut that failed and can be resumed.
+type pluginOperationError struct {
+	ID    string
+	Stage string // Stage that failed
+	err   error
+}
+
+func (e *pluginOperationError) Error() string {
+	return fmt.Sprintf("plugin cut %s failed at stage %s: %s", e.ID, e.Stage, e.err.Error())
+}
+
+func (e *pluginOperationError) Unwrap() error {
+	return e.err
+}
+
+// pluginStageIndex returns the position of the given stage in pluginStages, -1 if none.
+func pluginStageIndex(stage string) int {
+	for i, s := range pluginStages {
+		if s == stage {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func pluginOperationsDir(cfg *MatterbuildConfig) string {
+	if cfg.PluginOperationsDir != "" {
+		return cfg.PluginOperationsDir
+	}
+
+	return filepath.Join(os.TempDir(), "matterbuild-plugin-operations")
+}
+
+// newPluginOperation creates the work directory of a new plugin cut, after removing the ones
+// of cuts older than pluginOperationMaxAge.
+func newPluginOperation(cfg *MatterbuildConfig, owner, repositoryName, tag, assetName string, preRelease bool) (*pluginOperation, error) {
+	dir := pluginOperationsDir(cfg)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create plugin operations dir")
+	}
+	prunePluginOperations(dir, time.Now().Add(-pluginOperationMaxAge))
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate operation id")
+	}
+
+	now := time.Now().UTC()
+	op := &pluginOperation{
+		ID:         hex.EncodeToString(b),
+		Action:     pluginOperationCut,
+		Owner:      owner,
+		Repository: repositoryName,
+		Tag:        tag,
+		AssetName:  assetName,
+		PreRelease: preRelease,
+		KeyIDs:     make(map[string]string),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	op.dir = filepath.Join(dir, op.ID)
+
+	if err := os.Mkdir(op.dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create operation dir")
+	}
+
+	if err := op.save(); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// loadPluginOperation loads the checkpoint of the plugin cut with the given ID.
+func loadPluginOperation(cfg *MatterbuildConfig, id string) (*pluginOperation, error) {
+	if !pluginOperationIDPattern.MatchString(id) {
+		return nil, errors.Errorf("invalid operation id %q", id)
+	}
+
+	dir := filepath.Join(pluginOperationsDir(cfg), id)
+	data, err := os.ReadFile(filepath.Join(dir, pluginOperationFile))
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("operation %s not found, it may have completed or expired", id)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read operation %s", id)
+	}
+
+	var op pluginOperation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse operation %s", id)
+	}
+	if op.KeyIDs == nil {
+		op.KeyIDs = make(map[string]string)
+	}
+	op.dir = dir
+
+	return &op, nil
+}
+
+// prunePluginOperations removes the work directories of the plugin cuts last updated before
+// the given time. Unpublish records are kept.
+func prunePluginOperations(dir string, before time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		LogError("failed to list plugin operations err=%s", err.Error())
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !pluginOperationIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(dir, entry.Name(), pluginOperationFile))
+		if os.IsNotExist(err) {
+			// Work directory created without its operation file
+			info, err = entry.Info()
+		}
+		if err != nil || info.ModTime().After(before) {
+			continue
+		}
+
+		if _, running := runningPluginOperations.Load(entry.Name()); running {
+			continue
+		}
+
+		if isPluginUnpublishRecord(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+
+		LogInfo("Removing expired plugin operation %s", entry.Name())
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			LogError("failed to remove plugin operation %s err=%s", entry.Name(), err.Error())
+		}
+	}
+}
+
+// isPluginUnpublishRecord returns true if the operation in the given directory is the record of
+// an unpublish. Operations which can't be read are pruned like any other.
+func isPluginUnpublishRecord(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, pluginOperationFile))
+	if err != nil {
+		LogError("failed to read plugin operation %s err=%s", filepath.Base(dir), err.Error())
+		return false
+	}
+
+	var op pluginOperation
+	if err := json.Unmarshal(data, &op); err != nil {
+		LogError("failed to parse plugin operation %s err=%s", filepath.Base(dir), err.Error())
+		return false
+	}
+
+	return op.Action == pluginOperationUnpublish
+}
+
+// path returns the path of the given file of the work directory.
+func (op *pluginOperation) path(name string) string {
+	return filepath.Join(op.dir, name)
+}
+
+// done returns true if the given stage is completed.
+func (op *pluginOperation) done(stage string) bool {
+	return pluginStageIndex(stage) <= pluginStageIndex(op.Stage)
+}
+
+// next returns the first incomplete stage.
+func (op *pluginOperation) next() string {
+	for _, stage := range pluginStages {
+		if !op.done(stage) {
+			return stage
+		}
+	}
+
+	return ""
+}
+
+// checkpoint records the given stage as completed.
+func (op *pluginOperation) checkpoint(stage string) error {
+	op.Stage = stage
+	op.UpdatedAt = time.Now().UTC()
+	if err := op.save(); err != nil {
+		return errors.Wrapf(err, "failed to checkpoint stage %s", stage)
+	}
+
+	LogInfo("Plugin cut %s completed stage %s", op.ID, stage)
+	return nil
+}
+
+func (op *pluginOperation) save() error {
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal operation")
+	}
+
+	// Write then rename, not to leave a truncated checkpoint behind
+	tmpPath := op.path(pluginOperationFile + ".tmp")
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, "failed to write operation")
+	}
+
+	return errors.Wrap(os.Rename(tmpPath, op.path(pluginOperationFile)), "failed to write operation")
+}
+
+// lock marks the operation as running. Returns an error if it already is.
+func (op *pluginOperation) lock() error {
+	if _, running := runningPluginOperations.LoadOrStore(op.ID, struct{}{}); running {
+		return errors.Errorf("operation %s is already running", op.ID)
+	}
+
+	return nil
+}
+
+func (op *pluginOperation) unlock() {
+	runningPluginOperations.Delete(op.ID)
+}
+
+// remove removes the work directory of the operation.
+func (op *pluginOperation) remove() {
+	if err := os.RemoveAll(op.dir); err != nil {
+		LogError("failed to remove plugin operation %s err=%s", op.ID, err.Error())
+	}
+}
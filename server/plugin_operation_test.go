@@ -0,0 +1,158 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestPluginOperation(t *testing.T) {
+	cfg := &MatterbuildConfig{PluginOperationsDir: t.TempDir()}
+
+	op, err := newPluginOperation(cfg, "owner", "repo", "v1.0.0", "", true)
+	require.NoError(t, err)
+	require.Regexp(t, pluginOperationIDPattern, op.ID)
+	require.False(t, op.done(pluginStageDownloaded))
+	require.Equal(t, pluginStageDownloaded, op.next())
+
+	op.GithubPluginFile = "repo-1.0.0.tar.gz"
+	require.NoError(t, op.checkpoint(pluginStageDownloaded))
+	op.KeyIDs["repo-1.0.0.tar.gz"] = "ABCDEF"
+	require.NoError(t, op.checkpoint(pluginStageSplit))
+	require.True(t, op.done(pluginStageDownloaded))
+	require.True(t, op.done(pluginStageSplit))
+	require.False(t, op.done(pluginStageSigned))
+	require.Equal(t, pluginStageSigned, op.next())
+
+	loaded, err := loadPluginOperation(cfg, op.ID)
+	require.NoError(t, err)
+	require.Equal(t, op.Stage, loaded.Stage)
+	require.Equal(t, op.GithubPluginFile, loaded.GithubPluginFile)
+	require.Equal(t, op.KeyIDs, loaded.KeyIDs)
+	require.True(t, loaded.PreRelease)
+
+	require.NoError(t, op.lock())
+	require.EqualError(t, loaded.lock(), "operation "+op.ID+" is already running")
+	op.unlock()
+	require.NoError(t, loaded.lock())
+	loaded.unlock()
+
+	_, err = loadPluginOperation(cfg, "../../etc")
+	require.EqualError(t, err, `invalid operation id "../../etc"`)
+
+	_, err = loadPluginOperation(cfg, "0123456789ab")
+	require.EqualError(t, err, "operation 0123456789ab not found, it may have completed or expired")
+
+	record, err := newPluginOperation(cfg, "owner", "repo", "v0.9.0", "", false)
+	require.NoError(t, err)
+	record.Action = pluginOperationUnpublish
+	require.NoError(t, record.save())
+
+	corrupt, err := newPluginOperation(cfg, "owner", "repo", "v0.8.0", "", false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(corrupt.path(pluginOperationFile), []byte("{"), 0600))
+	halfCreated := filepath.Join(cfg.PluginOperationsDir, "0123456789ab")
+	require.NoError(t, os.Mkdir(halfCreated, 0700))
+
+	// Expired operations are pruned, even unreadable ones, unpublish records are kept
+	prunePluginOperations(cfg.PluginOperationsDir, time.Now().Add(time.Minute))
+	_, err = loadPluginOperation(cfg, op.ID)
+	require.Error(t, err)
+	require.NoDirExists(t, corrupt.dir)
+	require.NoDirExists(t, halfCreated)
+	_, err = loadPluginOperation(cfg, record.ID)
+	require.NoError(t, err)
+}
+
+func TestResumeCutPlugin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	owner := "owner"
+	repoName := "mattermost-plugin-demo"
+	tag := "v0.4.1"
+	release := &github.RepositoryRelease{ID: github.Int64(42), Assets: []github.ReleaseAsset{{ID: github.Int64(5), Name: github.String("mattermost-plugin-demo-v0.4.1.tar.gz")}}}
+
+	dir := t.TempDir()
+	privateKeyPath, publicKeyPath, _ := writeTestSigningKey(t, dir)
+	s3URL, s3Objects := startTestS3Server(t)
+
+	deniedS3Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+	}))
+	defer deniedS3Server.Close()
+
+	cfg := &MatterbuildConfig{
+		PluginSigningBackend:           "local",
+		PluginSigningPublicKeyPath:     publicKeyPath,
+		PluginSigningPrivateKeyPath:    privateKeyPath,
+		PluginSigningAWSAccessKey:      "access",
+		PluginSigningAWSSecretKey:      "secret",
+		PluginSigningAWSRegion:         "us-east-1",
+		PluginSigningAWSS3PluginBucket: "bucket",
+		PluginSigningAWSS3Endpoint:     deniedS3Server.URL,
+		PluginOperationsDir:            filepath.Join(dir, "operations"),
+	}
+
+	pluginFilePath := filepath.Join(dir, "plugin.tar.gz")
+	writeTestPlugin(t, pluginFilePath, "0.4.1")
+	pluginData, err := os.ReadFile(pluginFilePath)
+	require.NoError(t, err)
+
+	// The asset is downloaded and the files uploaded to GitHub only once
+	repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+	repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(release, nil, nil).AnyTimes()
+	repoMock.EXPECT().DownloadReleaseAsset(gomock.Any(), owner, repoName, int64(5)).Return(io.NopCloser(bytes.NewReader(pluginData)), "", nil)
+	repoMock.EXPECT().ListReleaseAssets(gomock.Any(), owner, repoName, int64(42), nil).Return(nil, nil, nil).AnyTimes()
+	repoMock.EXPECT().UploadReleaseAsset(gomock.Any(), owner, repoName, int64(42), gomock.Any(), gomock.Any()).Return(&github.ReleaseAsset{}, nil, nil).Times(5)
+	client := &GithubClient{Repositories: repoMock}
+
+	_, err = cutPlugin(ctx, cfg, client, owner, repoName, tag, "", false, false)
+	require.Error(t, err)
+	var opErr *pluginOperationError
+	require.ErrorAs(t, err, &opErr)
+	require.Equal(t, pluginStageS3, opErr.Stage)
+	require.Contains(t, err.Error(), "plugin cut "+opErr.ID+" failed at stage s3: failed to upload to s3")
+
+	op, err := loadPluginOperation(cfg, opErr.ID)
+	require.NoError(t, err)
+	require.Equal(t, pluginStageGithub, op.Stage)
+	require.Len(t, op.PlatformPluginFiles, 3)
+	require.FileExists(t, op.path("mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz.sig"))
+	require.Empty(t, s3Objects)
+
+	// A missing icon only matters to the Marketplace entry, which isn't asked for
+	op.Manifest.IconPath = "assets/missing.svg"
+	_, err = describeMarketplacePlugin(cfg, op, &pluginReleaseManifest{})
+	require.ErrorContains(t, err, "failed to read plugin icon")
+
+	cfg.PluginSigningAWSS3Endpoint = s3URL
+	result, err := runPluginOperation(ctx, cfg, client, op)
+	require.NoError(t, err)
+	require.Nil(t, result.Marketplace)
+	require.Len(t, result.PlatformPluginFiles, 3)
+	require.NotEmpty(t, result.Checksums)
+	require.Len(t, s3Objects, 12)
+	require.Equal(t, pluginData, s3Objects["release/mattermost-plugin-demo-v0.4.1.tar.gz"])
+
+	// The work directory is removed once the cut completes
+	require.NoDirExists(t, op.dir)
+	_, err = loadPluginOperation(cfg, op.ID)
+	require.Error(t, err)
+}
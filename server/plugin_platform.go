@@ -0,0 +1,78 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	elfArchs = map[elf.Machine]string{
+		elf.EM_X86_64:  "amd64",
+		elf.EM_AARCH64: "arm64",
+		elf.EM_386:     "386",
+		elf.EM_ARM:     "arm",
+	}
+	machoArchs = map[macho.Cpu]string{
+		macho.CpuAmd64: "amd64",
+		macho.CpuArm64: "arm64",
+	}
+	peArchs = map[uint16]string{
+		pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+		pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+		pe.IMAGE_FILE_MACHINE_I386:  "386",
+	}
+)
+
+// detectExecutablePlatforms returns the platforms, e.g. linux-amd64, the given executable runs on.
+// A universal macOS binary covers several platforms.
+func detectExecutablePlatforms(data []byte) ([]string, error) {
+	r := bytes.NewReader(data)
+
+	if f, err := elf.NewFile(r); err == nil {
+		goos := "linux"
+		if f.OSABI == elf.ELFOSABI_FREEBSD {
+			goos = "freebsd"
+		}
+		return executablePlatform(goos, elfArchs[f.Machine], f.Machine)
+	}
+
+	if f, err := macho.NewFile(r); err == nil {
+		return executablePlatform("darwin", machoArchs[f.Cpu], f.Cpu)
+	}
+
+	if f, err := macho.NewFatFile(r); err == nil {
+		var platforms []string
+		for _, arch := range f.Arches {
+			platform, err := executablePlatform("darwin", machoArchs[arch.Cpu], arch.Cpu)
+			if err != nil {
+				return nil, err
+			}
+			platforms = append(platforms, platform...)
+		}
+		sort.Strings(platforms)
+		return platforms, nil
+	}
+
+	if f, err := pe.NewFile(r); err == nil {
+		return executablePlatform("windows", peArchs[f.Machine], fmt.Sprintf("%#x", f.Machine))
+	}
+
+	return nil, errors.New("unknown executable format")
+}
+
+func executablePlatform(goos, goarch string, machine interface{}) ([]string, error) {
+	if goarch == "" {
+		return nil, errors.Errorf("unsupported %s architecture %v", goos, machine)
+	}
+
+	return []string{goos + "-" + goarch}, nil
+}
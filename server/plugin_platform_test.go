@@ -0,0 +1,117 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testELFHeader returns a minimal 64-bit little-endian ELF executable header.
+func testELFHeader(machine elf.Machine) []byte {
+	header := elf.Header64{
+		Type:    uint16(elf.ET_EXEC),
+		Machine: uint16(machine),
+		Version: uint32(elf.EV_CURRENT),
+		Ehsize:  64,
+	}
+	copy(header.Ident[:], []byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS64), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT), byte(elf.ELFOSABI_NONE)})
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, header)
+	return out.Bytes()
+}
+
+// testMachOHeader returns a minimal 64-bit Mach-O executable header.
+func testMachOHeader(cpu macho.Cpu) []byte {
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, macho.FileHeader{
+		Magic: macho.Magic64,
+		Cpu:   cpu,
+		Type:  macho.TypeExec,
+	})
+	out.Write(make([]byte, 4)) // Reserved field of 64-bit headers
+	return out.Bytes()
+}
+
+// testFatMachOHeader returns a universal Mach-O binary of the given architectures.
+func testFatMachOHeader(cpus ...macho.Cpu) []byte {
+	const headerSize = 8
+	const archSize = 20
+	offset := uint32(headerSize + archSize*len(cpus))
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, uint32(macho.MagicFat))
+	binary.Write(&out, binary.BigEndian, uint32(len(cpus)))
+
+	var arches [][]byte
+	for _, cpu := range cpus {
+		arch := testMachOHeader(cpu)
+		binary.Write(&out, binary.BigEndian, macho.FatArchHeader{
+			Cpu:    cpu,
+			Offset: offset,
+			Size:   uint32(len(arch)),
+		})
+		offset += uint32(len(arch))
+		arches = append(arches, arch)
+	}
+	for _, arch := range arches {
+		out.Write(arch)
+	}
+
+	return out.Bytes()
+}
+
+// testPEHeader returns a minimal PE executable header, followed by an empty string table.
+func testPEHeader(machine uint16) []byte {
+	const fileHeaderSize = 20
+	var out bytes.Buffer
+	dosHeader := make([]byte, 0x80)
+	copy(dosHeader, "MZ")
+	binary.LittleEndian.PutUint32(dosHeader[0x3c:], 0x80)
+	out.Write(dosHeader)
+	out.WriteString("PE\x00\x00")
+	binary.Write(&out, binary.LittleEndian, pe.FileHeader{
+		Machine:              machine,
+		PointerToSymbolTable: uint32(out.Len() + fileHeaderSize),
+	})
+	binary.Write(&out, binary.LittleEndian, uint32(4))
+	return out.Bytes()
+}
+
+func TestDetectExecutablePlatforms(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data     []byte
+		expected []string
+	}{
+		"linux amd64":      {testELFHeader(elf.EM_X86_64), []string{"linux-amd64"}},
+		"linux arm64":      {testELFHeader(elf.EM_AARCH64), []string{"linux-arm64"}},
+		"darwin amd64":     {testMachOHeader(macho.CpuAmd64), []string{"darwin-amd64"}},
+		"darwin arm64":     {testMachOHeader(macho.CpuArm64), []string{"darwin-arm64"}},
+		"darwin universal": {testFatMachOHeader(macho.CpuArm64, macho.CpuAmd64), []string{"darwin-amd64", "darwin-arm64"}},
+		"windows amd64":    {testPEHeader(pe.IMAGE_FILE_MACHINE_AMD64), []string{"windows-amd64"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			platforms, err := detectExecutablePlatforms(tc.data)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, platforms)
+		})
+	}
+
+	t.Run("unsupported architecture", func(t *testing.T) {
+		_, err := detectExecutablePlatforms(testELFHeader(elf.EM_MIPS))
+		require.Error(t, err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := detectExecutablePlatforms([]byte("#!/bin/sh\necho plugin\n"))
+		require.EqualError(t, err, "unknown executable format")
+	})
+}
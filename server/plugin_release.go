@@ -5,405 +5,440 @@ package server
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-	"github.com/eugenmayer/go-sshclient/sshwrapper"
+	"github.com/blang/semver"
 	"github.com/google/go-github/github"
-	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pkg/errors"
-	"github.com/pkg/sftp"
-	"golang.org/x/crypto/openpgp"
-	"golang.org/x/crypto/openpgp/armor"
-	"golang.org/x/crypto/ssh"
-
-	"github.com/mattermost/matterbuild/utils"
 )
 
-const pluginAssetTimeout = 50 * time.Minute
+const (
+	defaultPluginAssetTimeout             = 50 * time.Minute
+	pluginAssetPollIntervalWithoutWebhook = 30 * time.Second
+	pluginAssetWebhookPollInterval        = 5 * time.Minute
+)
 
 var ErrTagExists = errors.New("tag already exists")
 
+// pluginReleasesInProgress holds the plugin releases being cut, by lowercase owner/repo@tag.
+var pluginReleasesInProgress sync.Map
+
+// lockPluginRelease marks the release of the given tag as being cut. Returns false if it already
+// is, or a function to call once done otherwise.
+func lockPluginRelease(owner, repositoryName, tag string) (func(), bool) {
+	key := strings.ToLower(fmt.Sprintf("%s/%s@%s", owner, repositoryName, tag))
+	if _, inProgress := pluginReleasesInProgress.LoadOrStore(key, struct{}{}); inProgress {
+		return nil, false
+	}
+
+	return func() { pluginReleasesInProgress.Delete(key) }, true
+}
+
+// validatePluginTag checks that the tag is a semver version with a leading 'v'.
+func validatePluginTag(tag string) error {
+	if tag == "" {
+		return errors.New("Tag should not be empty")
+	}
+	if tag[0] != 'v' {
+		return errors.New("Tag must start with leading 'v'")
+	}
+
+	if _, err := semver.Parse(tag[1:]); err != nil {
+		return errors.Errorf("Tag must adhere to semver after leading 'v': %s", err.Error())
+	}
+
+	return nil
+}
+
+// cutPluginResult describes what cutPlugin published.
+type cutPluginResult struct {
+	PlatformPluginFiles []string // Names of the platform specific plugin tars
+	Notice              string   // Set when the plugin bundle is published as-is
+	Checksums           string   // Content of the published SHA256SUMS file
+
+	Marketplace *marketplacePlugin // Marketplace entry of the release, if asked for
+}
+
 // cutPlugin entry point to cutting a release for a plugin.
 // This method DOES NOT generate github plugin release asset (<plugin>.tar.gz).
 // It assumes the plugin release asset to be available on the repository's release.
-// This generates:
+// The plugin release asset is validated, then this generates:
 // 1. Plugin signature (uploaded to github)
-// 2. Platform specific plugin tars and their signatures (uploaded to s3 release bucket)
-func cutPlugin(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repositoryName, tag, assetName string, preRelease bool) error {
-	pluginRelease, err := getPluginRelease(ctx, client, owner, repositoryName, tag)
+// 2. Platform specific plugin tars and their signatures (uploaded to s3 release bucket), unless
+// the plugin is webapp-only or has a singular executable, in which case the bundle is published as-is
+// 3. <repo>-<tag>-SHA256SUMS and <repo>-<tag>-manifest.json describing the artifacts, and their
+// signatures (uploaded to both)
+// The Marketplace entry of the release is only described if marketplace is set.
+// If the cut fails past validation, its work directory is kept and a *pluginOperationError is
+// returned with the ID to resume it with runPluginOperation.
+func cutPlugin(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repositoryName, tag, assetName string, preRelease, marketplace bool) (*cutPluginResult, error) {
+	op, err := newPluginOperation(cfg, owner, repositoryName, tag, assetName, preRelease)
 	if err != nil {
-		return errors.Wrap(err, "failed to get plugin release")
+		return nil, errors.Wrap(err, "failed to create plugin operation")
 	}
+	op.Marketplace = marketplace
 
-	if preRelease {
-		if err = markTagAsPreRelease(ctx, client, owner, repositoryName, tag); err != nil {
-			return errors.Wrap(err, "failed to mark release as pre-release")
-		}
-	}
+	return runPluginOperation(ctx, cfg, client, op)
+}
 
-	pluginAsset, err := getPluginAsset(ctx, pluginRelease, assetName)
-	if err != nil {
-		return errors.Wrap(err, "failed to get plugin asset")
-	}
+// pluginStageFunc runs a stage of a plugin cut, recording what later stages need in op.
+type pluginStageFunc func(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error
 
-	// Download plugin tar into temp folder
-	tmpFolder, err := os.MkdirTemp("", pluginAsset.GetName())
-	if err != nil {
-		return errors.Wrap(err, "failed to create temp dir")
-	}
-	defer os.RemoveAll(tmpFolder)
+var pluginStageFuncs = map[string]pluginStageFunc{
+	pluginStageDownloaded: downloadPluginStage,
+	pluginStageSplit:      splitPluginStage,
+	pluginStageSigned:     signPluginStage,
+	pluginStageManifest:   releaseManifestPluginStage,
+	pluginStageGithub:     uploadToGithubPluginStage,
+	pluginStageS3:         uploadToS3PluginStage,
+}
 
-	githubPluginFilePath, err := downloadAsset(ctx, client, owner, repositoryName, pluginAsset, tmpFolder)
-	if err != nil {
-		return errors.Wrap(err, "failed to download asset")
+// runPluginOperation runs the incomplete stages of the given plugin cut, reusing the artifacts of
+// the completed ones. The work directory is removed once the cut completes, or fails validation.
+func runPluginOperation(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) (*cutPluginResult, error) {
+	if op.Action == pluginOperationUnpublish {
+		return nil, errors.Errorf("operation %s unpublished %s of %s, it cannot be resumed", op.ID, op.Tag, op.Repository)
 	}
 
-	// Split plugin into platform specific tars
-	platformPluginFilePaths, err := createPlatformPlugins(repositoryName, tag, githubPluginFilePath, tmpFolder)
-	if err != nil {
-		return errors.Wrap(err, "failed to create platform tars")
+	if err := op.lock(); err != nil {
+		return nil, err
 	}
+	defer op.unlock()
 
-	// Sign plugin tars and put them in tmpFolder. Signature files are assumed to be <path>.sig
-	err = signPlugins(Cfg, append(platformPluginFilePaths, githubPluginFilePath), tmpFolder)
-	if err != nil {
-		return errors.Wrap(err, "failed to sign plugin tars")
-	}
+	for _, stage := range pluginStages {
+		if op.done(stage) {
+			LogInfo("Plugin cut %s already completed stage %s", op.ID, stage)
+			continue
+		}
 
-	// Upload github plugin tar signature to github
-	githubPluginSignatureFilePath := githubPluginFilePath + ".sig"
-	if err := uploadFilesToGithub(ctx, client, owner, repositoryName, tag, []string{githubPluginSignatureFilePath}); err != nil {
-		return errors.Wrap(err, "failed to upload files to github")
-	}
+		if err := pluginStageFuncs[stage](ctx, cfg, client, op); err != nil {
+			// Resuming would validate the same bundle again
+			var validationErr *pluginValidationError
+			if errors.As(err, &validationErr) {
+				op.remove()
+				return nil, err
+			}
 
-	// Duplicate github plugin tar and its signature that follows s3 release bucket naming convention
-	s3PluginFilepath := filepath.Join(tmpFolder, fmt.Sprintf("%v-%v.tar.gz", repositoryName, tag))
-	if err := os.Symlink(githubPluginFilePath, s3PluginFilepath); err != nil {
-		return errors.Wrap(err, "failed to duplicate plugin file")
+			return nil, &pluginOperationError{ID: op.ID, Stage: stage, err: err}
+		}
+
+		if err := op.checkpoint(stage); err != nil {
+			return nil, &pluginOperationError{ID: op.ID, Stage: stage, err: err}
+		}
 	}
 
-	s3PluginSignatureFilepath := s3PluginFilepath + ".sig"
-	if err := os.Symlink(githubPluginSignatureFilePath, s3PluginSignatureFilepath); err != nil {
-		return errors.Wrap(err, "failed to duplicate signature file")
+	releaseManifest, err := op.releaseManifest()
+	if err != nil {
+		return nil, &pluginOperationError{ID: op.ID, Stage: pluginStageS3, err: err}
 	}
 
-	s3Bucket := []string{s3PluginFilepath, s3PluginSignatureFilepath}
-	for _, p := range platformPluginFilePaths {
-		s3Bucket = append(s3Bucket, p)
-		s3Bucket = append(s3Bucket, fmt.Sprintf("%s.sig", p))
+	var marketplace *marketplacePlugin
+	if op.Marketplace {
+		marketplace, err = describeMarketplacePlugin(cfg, op, releaseManifest)
+		if err != nil {
+			return nil, &pluginOperationError{ID: op.ID, Stage: pluginStageS3, err: errors.Wrap(err, "failed to describe marketplace entry")}
+		}
 	}
 
-	// Upload plugins and signatures to s3 release bucket
-	if err := uploadToS3(ctx, Cfg, s3Bucket); err != nil {
-		return errors.Wrap(err, "failed to upload to s3")
+	result := &cutPluginResult{
+		PlatformPluginFiles: op.PlatformPluginFiles,
+		Notice:              op.Notice,
+		Checksums:           releaseManifest.checksums(),
+		Marketplace:         marketplace,
 	}
+	op.remove()
 
-	return nil
+	return result, nil
 }
 
-func checkRepo(ctx context.Context, client *GithubClient, owner, repo string) error {
-	result, _, err := client.Search.Repositories(ctx, fmt.Sprintf("repo:%s/%s", owner, repo), nil)
+// describeMarketplacePlugin describes the release of the completed plugin cut as a Marketplace
+// entry, with the icon of the plugin bundle.
+func describeMarketplacePlugin(cfg *MatterbuildConfig, op *pluginOperation, releaseManifest *pluginReleaseManifest) (*marketplacePlugin, error) {
+	iconData, err := readPluginIconData(op.path(op.GithubPluginFile), op.Manifest)
 	if err != nil {
-		return errors.Wrapf(err, "failed to fetch github repo %s", repo)
-	}
-
-	if result.GetTotal() == 0 {
-		return errors.Errorf("looks like this repository is not part of the org or does not exist. Repo: %s", repo)
+		return nil, err
 	}
 
-	return nil
+	return newMarketplacePlugin(cfg, op.Owner, op.Repository, op.Tag, op.Manifest, iconData, releaseManifest, op.dir)
 }
 
-func getReleaseByTag(ctx context.Context, client *GithubClient, owner, repositoryName, tag string) (*github.RepositoryRelease, error) {
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repositoryName, tag)
+// downloadPluginStage downloads the plugin release asset, once it is available.
+func downloadPluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	pluginAsset, err := waitForPluginAsset(ctx, cfg, client, op.Owner, op.Repository, op.Tag, op.AssetName)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get release by tag")
+		return errors.Wrap(err, "failed to get plugin asset")
 	}
 
-	return release, nil
-}
-
-// createTag creates a new tag at the given commit for the repository.
-// Returns ErrTagExists if tag already exists, nil if successful and an error otherwise.
-func createTag(ctx context.Context, client *GithubClient, owner, repository, tag, commitSHA string) error {
-	tagRef := fmt.Sprintf("tags/%s", tag)
-	refs, _, err := client.Git.GetRefs(ctx, owner, repository, tagRef)
-	if err != nil {
-		var gerr *github.ErrorResponse
-		if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
-			LogInfo("tag %s was not found, creating tag", tag)
-		} else {
-			return errors.Wrapf(err, "failed to get github tag")
+	if op.PreRelease {
+		if err = markTagAsPreRelease(ctx, client, op.Owner, op.Repository, op.Tag); err != nil {
+			return errors.Wrap(err, "failed to mark release as pre-release")
 		}
 	}
-	for _, ref := range refs {
-		if strings.HasSuffix(ref.GetRef(), tagRef) {
-			return ErrTagExists
-		}
+
+	githubPluginFilePath, err := downloadAsset(ctx, client, op.Owner, op.Repository, pluginAsset, op.dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to download asset")
 	}
+	op.GithubPluginFile = filepath.Base(githubPluginFilePath)
 
-	if commitSHA == "" {
-		// Use the default branch's tip if commitSHA is not provided, or master if not available
-		var repo *github.Repository
-		repo, _, err = client.Repositories.Get(ctx, owner, repository)
+	return nil
+}
 
-		branch := "master"
-		if err == nil && repo.GetDefaultBranch() != "" {
-			branch = repo.GetDefaultBranch()
-		}
+// splitPluginStage validates the plugin bundle and splits it into platform specific tars.
+func splitPluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	githubPluginFilePath := op.path(op.GithubPluginFile)
 
-		var ref *github.Reference
-		ref, _, err = client.Git.GetRef(ctx, owner, repository, "heads/"+branch)
-		if err != nil {
-			return errors.Wrap(err, "failed to get github ref")
-		}
+	// Refuse to sign an invalid bundle
+	if err := validatePluginBundle(githubPluginFilePath, op.Tag); err != nil {
+		return err
+	}
 
-		commitSHA = *ref.Object.SHA
-	} else {
-		// Check if sha exists
-		_, _, err = client.Repositories.GetCommit(ctx, owner, repository, commitSHA)
-		if err != nil {
-			return errors.Wrap(err, "failed to fetch sha details")
-		}
+	manifest, err := readPluginManifest(githubPluginFilePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read plugin manifest")
 	}
 
-	tagObject := &github.GitObject{
-		SHA:  github.String(commitSHA),
-		Type: github.String("commit"),
+	platformPluginFilePaths, notice, err := createPlatformPlugins(op.Repository, op.Tag, githubPluginFilePath, op.dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create platform tars")
 	}
 
-	githubTag := &github.Tag{
-		Tag:     github.String(tag),
-		Message: github.String(tag),
-		Object:  tagObject,
+	op.Manifest = manifest
+	op.Notice = notice
+	op.PlatformPluginFiles = nil
+	for _, p := range platformPluginFilePaths {
+		op.PlatformPluginFiles = append(op.PlatformPluginFiles, filepath.Base(p))
 	}
 
-	if _, _, err = client.Git.CreateTag(ctx, owner, repository, githubTag); err != nil {
-		return errors.Wrap(err, "failed to create tag")
+	return nil
+}
+
+// signPluginStage signs the plugin tars. Signature files are assumed to be <path>.sig
+func signPluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	signer, err := newSigner(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create plugin signer")
 	}
 
-	refTag := &github.Reference{
-		Ref:    github.String(fmt.Sprintf("tags/%s", tag)),
-		Object: tagObject,
+	filePaths := []string{op.path(op.GithubPluginFile)}
+	for _, name := range op.PlatformPluginFiles {
+		filePaths = append(filePaths, op.path(name))
 	}
 
-	if _, _, err = client.Git.CreateRef(ctx, owner, repository, refTag); err != nil {
-		return errors.Wrap(err, "failed to create ref")
+	keyIDs, err := signPlugins(ctx, cfg, signer, filePaths)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign plugin tars")
+	}
+
+	for filePath, keyID := range keyIDs {
+		op.KeyIDs[filepath.Base(filePath)] = keyID
 	}
 
 	return nil
 }
 
-// signPlugins signs plugin tar files and saves them in the tmpFolder.
-// Signature files are named <filePath>.sig.
-func signPlugins(cfg *MatterbuildConfig, filePaths []string, tmpFolder string) error {
-	// Copy files to remote server.
-	remotePaths, err := copyFilesToRemoteServer(cfg, filePaths)
-	if err != nil {
-		return errors.Wrap(err, "error while copying files")
+// releaseManifestPluginStage describes the published artifacts in checksums and manifest files,
+// signed as well.
+func releaseManifestPluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	// Duplicate github plugin tar and its signature that follows s3 release bucket naming convention,
+	// unless the github asset already follows it
+	s3PluginFile := op.s3PluginFile()
+	if s3PluginFile != op.GithubPluginFile {
+		for _, ext := range []string{"", ".sig"} {
+			if _, err := os.Lstat(op.path(s3PluginFile + ext)); err == nil {
+				continue
+			}
+
+			if err := os.Symlink(op.path(op.GithubPluginFile+ext), op.path(s3PluginFile+ext)); err != nil {
+				return errors.Wrap(err, "failed to duplicate plugin file")
+			}
+		}
 	}
 
-	// Sign files on remote server.
-	remoteSignaturePaths, err := signFilesOnRemoteServer(cfg, remotePaths)
+	releaseManifest, err := op.releaseManifest()
 	if err != nil {
-		return errors.Wrap(err, "error while signing files")
+		return err
 	}
 
-	// Fetch signatures from remote server.
-	if err := copyFilesFromRemoteServer(cfg, remoteSignaturePaths, tmpFolder); err != nil {
-		return errors.Wrap(err, "error while copying remote files")
+	releaseManifestFilePaths, err := writePluginReleaseManifest(op.dir, releaseManifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to write release manifest")
 	}
 
-	// Verify signatures.
-	if err := verifySignatures(filePaths); err != nil {
-		return errors.Wrap(err, "failed signature verification")
+	signer, err := newSigner(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create plugin signer")
 	}
 
-	// All is well, remove *.tar.gz files from remote server.
-	if err := removeFilesFromRemoteServer(cfg, remotePaths); err != nil {
-		return errors.Wrap(err, "failed to remove files from remote server")
+	if _, err = signPlugins(ctx, cfg, signer, releaseManifestFilePaths); err != nil {
+		return errors.Wrap(err, "failed to sign release manifest")
 	}
 
 	return nil
 }
 
-// copyFilesFromRemoteServer copies remoteFiles to pluginFolder.
-func copyFilesFromRemoteServer(cfg *MatterbuildConfig, remoteFiles []string, pluginFolder string) error {
-	LogInfo("Copying files from remote server")
-
-	sftp, err := getPluginSigningSftpClient(cfg)
-	if err != nil {
-		return errors.Wrap(err, "failed to get sftp client")
+// uploadToGithubPluginStage uploads the github plugin tar signature and the release manifest to github.
+func uploadToGithubPluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	filePaths := append([]string{op.path(op.GithubPluginFile + ".sig")}, op.releaseManifestFilePaths()...)
+	if err := uploadFilesToGithub(ctx, client, op.Owner, op.Repository, op.Tag, filePaths); err != nil {
+		return errors.Wrap(err, "failed to upload files to github")
 	}
-	defer sftp.Close()
 
-	for _, remoteFile := range remoteFiles {
-		srcFile, err := sftp.Open(remoteFile)
-		if err != nil {
-			return errors.Wrapf(err, "failed to open remote file %s,", remoteFile)
-		}
-		defer srcFile.Close()
+	return nil
+}
 
-		destination := filepath.Join(pluginFolder, filepath.Base(remoteFile))
-		LogInfo("copying %s -> %s", remoteFile, destination)
-		dstFile, err := os.Create(destination)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create file %s,", destination)
-		}
-		defer dstFile.Close()
+// uploadToS3PluginStage uploads the plugins, their signatures and the release manifest to the s3 release bucket.
+func uploadToS3PluginStage(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, op *pluginOperation) error {
+	s3PluginFilePath := op.path(op.s3PluginFile())
+	filePaths := []string{s3PluginFilePath, s3PluginFilePath + ".sig"}
+	for _, name := range op.PlatformPluginFiles {
+		filePaths = append(filePaths, op.path(name), op.path(name+".sig"))
+	}
+	filePaths = append(filePaths, op.releaseManifestFilePaths()...)
 
-		if _, err := srcFile.WriteTo(dstFile); err != nil {
-			return errors.Wrap(err, "error while reading from remote buffer")
-		}
+	if err := uploadToS3(ctx, cfg, filePaths); err != nil {
+		return errors.Wrap(err, "failed to upload to s3")
 	}
 
-	LogInfo("Done copying files from remote server")
 	return nil
 }
 
-func copyFilesToRemoteServer(cfg *MatterbuildConfig, filePaths []string) ([]string, error) {
-	LogInfo("Copying files to the signing server")
-	var result []string
+// s3PluginFile returns the name of the plugin tar in the s3 release bucket.
+func (op *pluginOperation) s3PluginFile() string {
+	return fmt.Sprintf("%v-%v.tar.gz", op.Repository, op.Tag)
+}
+
+// releaseManifest describes the plugin tars of the work directory.
+func (op *pluginOperation) releaseManifest() (*pluginReleaseManifest, error) {
+	releaseManifest := &pluginReleaseManifest{
+		Repository:  op.Repository,
+		Tag:         op.Tag,
+		SourceAsset: op.GithubPluginFile,
+	}
 
-	sftp, err := getPluginSigningSftpClient(cfg)
+	artifact, err := newPluginArtifact(op.path(op.GithubPluginFile), op.s3PluginFile(), "", op.KeyIDs[op.GithubPluginFile])
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get sftp client")
+		return nil, errors.Wrap(err, "failed to describe plugin tar")
 	}
-	defer sftp.Close()
+	releaseManifest.Artifacts = append(releaseManifest.Artifacts, artifact)
 
-	for _, filePath := range filePaths {
-		f, err := os.Open(filePath)
+	platformPrefix := fmt.Sprintf("%v-%v-", op.Repository, op.Tag)
+	for _, name := range op.PlatformPluginFiles {
+		platform := strings.TrimSuffix(strings.TrimPrefix(name, platformPrefix), ".tar.gz")
+		artifact, err = newPluginArtifact(op.path(name), name, platform, op.KeyIDs[name])
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to open file %s,", filePath)
+			return nil, errors.Wrap(err, "failed to describe platform tar")
 		}
-		defer f.Close()
-
-		serverPath := filepath.Join("/tmp", filepath.Base(filePath))
-		LogInfo("copying %s -> %s", filePath, serverPath)
+		releaseManifest.Artifacts = append(releaseManifest.Artifacts, artifact)
+	}
 
-		// Open the source file
-		srcFile, err := sftp.Create(serverPath)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to create remote file %s,", serverPath)
-		}
-		defer srcFile.Close()
+	return releaseManifest, nil
+}
 
-		if _, err := srcFile.ReadFrom(f); err != nil {
-			return nil, errors.Wrap(err, "failed to read from file")
-		}
+// releaseManifestFilePaths returns the paths of the checksums and manifest files, and of their signatures.
+func (op *pluginOperation) releaseManifestFilePaths() []string {
+	prefix := fmt.Sprintf("%s-%s", op.Repository, op.Tag)
 
-		result = append(result, serverPath)
+	var filePaths []string
+	for _, name := range []string{prefix + "-SHA256SUMS", prefix + "-manifest.json"} {
+		filePaths = append(filePaths, op.path(name), op.path(name+".sig"))
 	}
 
-	LogInfo("Done copying")
-	return result, nil
+	return filePaths
 }
 
-func removeFilesFromRemoteServer(cfg *MatterbuildConfig, remoteFiles []string) error {
-	LogInfo("Removing files from remote server")
-
-	sftp, err := getPluginSigningSftpClient(cfg)
-	if err != nil {
-		return errors.Wrap(err, "failed to get sftp client")
+// checkRepo checks that the repository of the owner exists, is not archived, and is a plugin
+// repository matterbuild may release: allowed by PluginRepositories and PluginRepositoriesDenied,
+// and with all the PluginRepositoryTopics.
+func checkRepo(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repo string) error {
+	if !isPluginRepositoryAllowed(cfg, repo) {
+		return errors.Errorf("repository %s is not allowed to be released by matterbuild", repo)
 	}
-	defer sftp.Close()
 
-	for _, remoteFile := range remoteFiles {
-		if err := sftp.Remove(remoteFile); err != nil {
-			return errors.Wrapf(err, "failed to remove %s,", remoteFile)
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		var gerr *github.ErrorResponse
+		if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
+			return errors.Errorf("looks like this repository is not part of the org or does not exist. Repo: %s", repo)
 		}
+		return errors.Wrapf(err, "failed to fetch github repo %s", repo)
 	}
 
-	LogInfo("Done copying files from remote server")
-	return nil
-}
-
-// signFilesOnRemoteServer signs and removes files from the remote server.
-// Returns signature filepaths.
-func signFilesOnRemoteServer(cfg *MatterbuildConfig, remoteFilePaths []string) ([]string, error) {
-	LogInfo("Starting to sign %s", remoteFilePaths)
-	var result []string
-
-	clientConfig, err := getSSHClientConfig(cfg.PluginSigningSSHUser, cfg.PluginSigningSSHKeyPath, cfg.PluginSigningSSHPublicCertPath, cfg.PluginSigningSSHHostPublicKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to setup client config")
+	// GitHub redirects the renamed and transferred repositories to their new location
+	if !strings.EqualFold(repository.GetOwner().GetLogin(), owner) || !strings.EqualFold(repository.GetName(), repo) {
+		return errors.Errorf("repository %s/%s was moved to %s, use its new name", owner, repo, repository.GetFullName())
 	}
-	sshClient := sshwrapper.NewSshApi(cfg.PluginSigningSSHHost, 22, cfg.PluginSigningSSHUser, cfg.PluginSigningSSHKeyPath)
-	sshClient.SshConfig = clientConfig
 
-	for _, remoteFilePath := range remoteFilePaths {
-		LogInfo("Signing " + remoteFilePath)
+	if repository.GetArchived() {
+		return errors.Errorf("repository %s is archived", repo)
+	}
 
-		stdout, stderr, err := sshClient.Run(fmt.Sprintf("sudo -u signer /opt/plugin-signer/sign_plugin.sh %s", remoteFilePath))
-		LogInfo(stdout)
-		LogInfo(stderr)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to run signer script")
+	for _, topic := range cfg.PluginRepositoryTopics {
+		found := false
+		for _, repoTopic := range repository.Topics {
+			if strings.EqualFold(repoTopic, topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("repository %s is missing the topic %s", repo, topic)
 		}
-
-		result = append(result, fmt.Sprintf("/opt/plugin-signer/output/%s.sig", filepath.Base(remoteFilePath)))
 	}
 
-	LogInfo("Done signing")
-	return result, nil
+	return nil
 }
 
-// verifySignatures verifies plugin files, assumes signatures are <filepath>.sig.
-func verifySignatures(pluginFilePaths []string) error {
-	block, err := armor.Decode(bytes.NewReader(mattermostPluginPublicKey))
-	if err != nil {
-		return errors.Wrap(err, "failed to decode public key")
+// isPluginRepositoryAllowed returns true if the repository matches one of the PluginRepositories
+// patterns, or if none is configured, and none of the PluginRepositoriesDenied patterns.
+func isPluginRepositoryAllowed(cfg *MatterbuildConfig, repo string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); matched {
+				return true
+			}
+		}
+		return false
 	}
 
-	keyring, err := openpgp.ReadKeyRing(block.Body)
-	if err != nil {
-		return errors.Wrap(err, "can't read public key")
+	if matches(cfg.PluginRepositoriesDenied) {
+		return false
 	}
 
-	for _, pluginFilePath := range pluginFilePaths {
-		signedFile, err := os.Open(pluginFilePath)
-		if err != nil {
-			return errors.Wrap(err, "cannot read signed file")
-		}
-		defer signedFile.Close()
-
-		// Assume signature is always <filepath>.sig
-		signatureFile, err := os.Open(fmt.Sprintf("%s.sig", pluginFilePath))
-		if err != nil {
-			return errors.Wrap(err, "cannot read signature file")
-		}
-		defer signatureFile.Close()
+	return len(cfg.PluginRepositories) == 0 || matches(cfg.PluginRepositories)
+}
 
-		if _, err = openpgp.CheckDetachedSignature(keyring, signedFile, signatureFile); err != nil {
-			return errors.Wrap(err, "error while checking the signature")
-		}
+func getReleaseByTag(ctx context.Context, client *GithubClient, owner, repositoryName, tag string) (*github.RepositoryRelease, error) {
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repositoryName, tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get release by tag")
 	}
 
-	LogInfo("Signatures verified for %+v", pluginFilePaths)
-	return nil
+	return release, nil
 }
 
 // createPlatformPlugins splits plugin tar into platform specific plugin tars.
-// Returns paths to platform plugin tars if successful, or an error otherwise.
-func createPlatformPlugins(repositoryName, tag, pluginFilePath, pluginFolder string) ([]string, error) {
-	platformBinaries, err := findPlatformBinaries(pluginFilePath)
+// Returns paths to platform plugin tars if successful, or an error otherwise. Plugins that
+// cannot be split are published as-is: no platform tars are returned, but a notice
+// describing the platforms the bundle covers.
+func createPlatformPlugins(repositoryName, tag, pluginFilePath, pluginFolder string) ([]string, string, error) {
+	platformBinaries, notice, err := findPlatformBinaries(pluginFilePath)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var result []string
@@ -411,48 +446,23 @@ func createPlatformPlugins(repositoryName, tag, pluginFilePath, pluginFolder str
 		platformTarPath := filepath.Join(pluginFolder, fmt.Sprintf("%v-%v-%v.tar.gz", repositoryName, tag, platform))
 		err := createPlatformPlugin(pluginFilePath, binary, platformTarPath)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to create platform tar for %s", platformTarPath)
+			return nil, "", errors.Wrapf(err, "failed to create platform tar for %s", platformTarPath)
 		}
 
 		// Verify if this tar contains the correct platform binary
 		found, err := archiveContains(platformTarPath, "plugin-")
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to check files in archive %s,", platformTarPath)
+			return nil, "", errors.Wrapf(err, "failed to check files in archive %s,", platformTarPath)
 		}
 		if len(found) != 1 || found[0] != platformBinaries[platform] {
-			return nil, errors.Errorf("found wrong platform binary in %s, expected %s, but found %v",
+			return nil, "", errors.Errorf("found wrong platform binary in %s, expected %s, but found %v",
 				platformTarPath, platformBinaries[platform], found)
 		}
 
 		result = append(result, platformTarPath)
 	}
 
-	return result, nil
-}
-
-// getTarCmdStr returns the appropriate "tar" binary to use.
-func getTarCmdStr() string {
-	// tar isn't full-featured enough on MacOS: use gtar instead.
-	if runtime.GOOS == "darwin" {
-		return "gtar"
-	}
-
-	return "tar"
-}
-
-// unpackPlugin unpacks the given plugin.tar.gz into the given temporary directory.
-func unpackPlugin(pluginFilePath, tmpDir string) error {
-	// Extract the plugin file to the temporary directory.
-	catCmd := exec.Command("cat", pluginFilePath)
-	gunzipCmd := exec.Command("gunzip")
-	tarCmd := exec.Command(getTarCmdStr(), "-C", tmpDir, "-x")
-	cmds := []*exec.Cmd{catCmd, gunzipCmd, tarCmd}
-	utils.AssemblePipes(cmds, os.Stdin, os.Stdout)
-	if err := utils.RunCmds(cmds); err != nil {
-		return errors.Wrapf(err, "failed to decompress and extract to temporary directory")
-	}
-
-	return nil
+	return result, notice, nil
 }
 
 // createPlatformPlugin takes a given plugin.tar.gz and creates a new one at the given path
@@ -460,54 +470,25 @@ func unpackPlugin(pluginFilePath, tmpDir string) error {
 // easy installation, but we strip out the unnecessary packages when pre-packaging
 // for a specific platform build of Mattermost.
 func createPlatformPlugin(pluginFilePath, binary, platformTarPath string) error {
-	dir, err := os.MkdirTemp("", "platform-plugin-*")
+	var names []string
+	err := walkPluginArchive(pluginFilePath, func(name string, _ *tar.Header, _ io.Reader) error {
+		names = append(names, name)
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, "failed to create temporary directory")
+		return errors.Wrap(err, "failed to read plugin")
 	}
-	defer os.RemoveAll(dir) // clean up
 
-	err = unpackPlugin(pluginFilePath, dir)
-	if err != nil {
-		return errors.Wrap(err, "failed to unpack plugin")
-	}
-
-	// Re-create with some files filtered out. Also, note that we rely on shell cmds due to
-	// previously being unable to achieve gzip level compressions with golang archive api.
-	dirs, err := os.ReadDir(dir)
-	if err != nil || len(dirs) != 1 {
-		return errors.Wrap(err, "error finding plugin directory")
-	}
-	pluginDir := dirs[0].Name()
-	files, err := filepath.Glob(fmt.Sprintf("%s/%s/server/dist/plugin-*", dir, pluginDir))
-	if err != nil {
-		return errors.Wrap(err, "error finding plugin files")
-	}
-	for _, f := range files {
-		if !strings.HasSuffix(f, binary) {
-			err = os.Remove(f)
-			if err != nil {
-				return errors.Wrap(err, "error removing unneeded plugin binaries")
-			}
-		}
-	}
-
-	f, err := os.Create(platformTarPath)
+	binariesDir := path.Join(pluginArchiveRoot(names), "server", "dist")
+	err = repackPlugin(pluginFilePath, platformTarPath, func(name string) bool {
+		base := path.Base(name)
+		return path.Dir(name) != binariesDir || !strings.HasPrefix(base, "plugin-") || base == binary
+	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to create platform tar file %s", platformTarPath)
 	}
-	tarParams := []string{"-C", dir, "-c"}
-	tarParams = append(tarParams, ".")
-
-	tarCmd := exec.Command(getTarCmdStr(), tarParams...)
-	gzipCmd := exec.Command("gzip")
-	cmds := []*exec.Cmd{tarCmd, gzipCmd}
-	utils.AssemblePipes(cmds, os.Stdin, f)
-	if err = utils.RunCmds(cmds); err != nil {
-		f.Close()
-		return errors.Wrapf(err, "failed to exclude, tar and recompress")
-	}
 
-	return f.Close()
+	return nil
 }
 
 // downloadAsset Downloads asset into a given folder and returns its path.
@@ -555,77 +536,89 @@ func downloadAsset(ctx context.Context, client *GithubClient, owner, repositoryN
 	return "", errors.Errorf("failed to download release asset %s", asset.GetName())
 }
 
-// getPluginRelease polls till it finds the plugin release.
-func getPluginRelease(ctx context.Context, githubClient *GithubClient, owner, repo, tag string) (*github.RepositoryRelease, error) {
-	LogInfo("Checking if the release is available")
+// waitForPluginAsset waits till the release of the given tag has the plugin tar. The release is
+// fetched again whenever a release webhook is received for the repository, or at each poll
+// interval otherwise.
+func waitForPluginAsset(ctx context.Context, cfg *MatterbuildConfig, githubClient *GithubClient, owner, repo, tag, assetName string) (*github.ReleaseAsset, error) {
+	if assetName != "" {
+		LogInfo("Waiting for the release asset with name %q", assetName)
+	} else {
+		LogInfo("Waiting for the release asset")
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, pluginAssetTimeout)
+	ctx, cancel := context.WithTimeout(ctx, pluginAssetTimeout(cfg))
 	defer cancel()
 
+	wake, stop := pluginReleaseNotifier.wait(owner, repo)
+	defer stop()
+
 	for {
 		release, _, err := githubClient.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
 		if err != nil {
 			var gerr *github.ErrorResponse
-			if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
-				LogInfo("get release by tag %s was not found, trying again shortly", tag)
-			} else {
+			if !errors.As(err, &gerr) || gerr.Response.StatusCode != http.StatusNotFound {
 				return nil, errors.Wrap(err, "failed to get release by tag")
 			}
-		}
-
-		if release != nil {
-			return release, nil
+			LogInfo("get release by tag %s was not found, trying again shortly", tag)
+		} else {
+			asset, err := findPluginAsset(release, assetName)
+			if err != nil {
+				return nil, err
+			}
+			if asset != nil {
+				return asset, nil
+			}
+			LogInfo("Release found but no assets yet. Still waiting...")
 		}
 
 		select {
 		case <-ctx.Done():
-			return nil, errors.New("timed out waiting for ok response")
-		case <-time.After(30 * time.Second):
+			return nil, errors.Errorf("timed out waiting for the release asset of %s", tag)
+		case <-wake:
+			LogInfo("Release event received for %s/%s", owner, repo)
+		case <-time.After(pluginAssetPollInterval(cfg)):
 		}
 	}
 }
 
-// getPluginAsset polls till it finds the plugin tar file. If no asset
-// name provided, it will ensure that there is only one .tar.gz file
-// and use it instead
-func getPluginAsset(ctx context.Context, release *github.RepositoryRelease, assetName string) (*github.ReleaseAsset, error) {
-	if assetName != "" {
-		LogInfo("Checking if the release asset with name %q is available", assetName)
-	} else {
-		LogInfo("Checking if the release asset is available")
+// findPluginAsset finds the plugin tar file of the release. If no asset name provided, it will
+// ensure that there is only one .tar.gz file. Returns nil if the release has no plugin tar yet.
+func findPluginAsset(release *github.RepositoryRelease, assetName string) (*github.ReleaseAsset, error) {
+	var foundPluginAsset *github.ReleaseAsset
+	for i := range release.Assets {
+		name := release.Assets[i].GetName()
+		if assetName != "" {
+			if assetName == name {
+				return &release.Assets[i], nil
+			}
+		} else if strings.HasSuffix(name, ".tar.gz") {
+			if foundPluginAsset != nil {
+				return nil, errors.Errorf("found unexpected file %s", name)
+			}
+			foundPluginAsset = &release.Assets[i]
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, pluginAssetTimeout)
-	defer cancel()
+	return foundPluginAsset, nil
+}
 
-	for {
-		var foundPluginAsset *github.ReleaseAsset
-		for i := range release.Assets {
-			name := release.Assets[i].GetName()
-			if assetName != "" {
-				if assetName == name {
-					foundPluginAsset = &release.Assets[i]
-					break
-				}
-			} else if strings.HasSuffix(name, ".tar.gz") {
-				if foundPluginAsset != nil {
-					return nil, errors.Errorf("found unexpected file %s", name)
-				}
-				foundPluginAsset = &release.Assets[i]
-			}
-		}
+// pluginAssetTimeout returns how long to wait for the plugin release asset.
+func pluginAssetTimeout(cfg *MatterbuildConfig) time.Duration {
+	if cfg.PluginAssetTimeoutMinutes > 0 {
+		return time.Duration(cfg.PluginAssetTimeoutMinutes) * time.Minute
+	}
 
-		if foundPluginAsset != nil {
-			return foundPluginAsset, nil
-		}
-		LogInfo("Release found but no assets yet. Still waiting...")
+	return defaultPluginAssetTimeout
+}
 
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("timed out waiting for ok response")
-		case <-time.After(30 * time.Second):
-		}
+// pluginAssetPollInterval returns how often to fetch the release while waiting for the plugin
+// release asset. Releases are fetched less often when release webhooks are received.
+func pluginAssetPollInterval(cfg *MatterbuildConfig) time.Duration {
+	if cfg.GithubWebhookSecret != "" {
+		return pluginAssetWebhookPollInterval
 	}
+
+	return pluginAssetPollIntervalWithoutWebhook
 }
 
 func markTagAsPreRelease(ctx context.Context, githubClient *GithubClient, owner, repo, tag string) error {
@@ -702,12 +695,22 @@ func getReleaseAsset(ctx context.Context, owner string, githubClient *GithubClie
 	return nil, errors.Errorf("could not find github release asset %s", assetName)
 }
 
+// newS3Session creates an AWS session for the plugin release bucket.
+func newS3Session(cfg *MatterbuildConfig) *session.Session {
+	creds := credentials.NewStaticCredentials(cfg.PluginSigningAWSAccessKey, cfg.PluginSigningAWSSecretKey, "")
+	awsCfg := aws.NewConfig().WithRegion(cfg.PluginSigningAWSRegion).WithCredentials(creds)
+	if cfg.PluginSigningAWSS3Endpoint != "" {
+		// S3 compatible storage, e.g. a local fake for development and tests
+		awsCfg = awsCfg.WithEndpoint(cfg.PluginSigningAWSS3Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	return session.Must(session.NewSession(awsCfg))
+}
+
 func uploadToS3(ctx context.Context, cfg *MatterbuildConfig, filePaths []string) error {
 	LogInfo("Uploading files to S3")
 
-	creds := credentials.NewStaticCredentials(cfg.PluginSigningAWSAccessKey, cfg.PluginSigningAWSSecretKey, "")
-	awsCfg := aws.NewConfig().WithRegion(cfg.PluginSigningAWSRegion).WithCredentials(creds)
-	awsSession := session.Must(session.NewSession(awsCfg))
+	awsSession := newS3Session(cfg)
 
 	for _, filePath := range filePaths {
 		f, err := os.Open(filePath)
@@ -732,66 +735,40 @@ func uploadToS3(ctx context.Context, cfg *MatterbuildConfig, filePaths []string)
 	return nil
 }
 
-func getPluginSigningSftpClient(cfg *MatterbuildConfig) (*sftp.Client, error) {
-	clientConfig, err := getSSHClientConfig(cfg.PluginSigningSSHUser, cfg.PluginSigningSSHKeyPath, cfg.PluginSigningSSHPublicCertPath, cfg.PluginSigningSSHHostPublicKey)
+// findPlatformBinaries finds the binaries for which the plugin was compiled. Plugins without
+// per-platform executables have no binaries to split on, and get a notice describing the
+// platforms their bundle covers instead.
+func findPlatformBinaries(filePath string) (map[string]string, string, error) {
+	manifest, err := readPluginManifest(filePath)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to setup client config")
+		return nil, "", errors.Wrap(err, "failed to find manifest")
 	}
 
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%v:22", cfg.PluginSigningSSHHost), clientConfig)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to setup client config")
-	}
-
-	sftp, err := sftp.NewClient(client)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to setup sftp client")
-	}
-
-	return sftp, nil
-}
-
-// findPlatformBinaries finds the binaries for which the plugin was compiled
-func findPlatformBinaries(filePath string) (map[string]string, error) {
-	tmpDir, err := os.MkdirTemp("", "platform-plugin-*")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create temporary directory")
-	}
-	defer os.RemoveAll(tmpDir) // clean up
-
-	err = unpackPlugin(filePath, tmpDir)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to unpack plugin")
-	}
-
-	dir, err := os.ReadDir(tmpDir)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read tmp dir")
-	}
+	if manifest.Server == nil {
+		if manifest.Webapp == nil {
+			return nil, "", errors.New("neither server nor webapp defined")
+		}
 
-	// If the root of the plugin bundle consists of exactly one directory, assume the plugin
-	// is contained therein. Otherwise the root directory is expected to contain the plugin.
-	if len(dir) == 1 && dir[0].IsDir() {
-		tmpDir = filepath.Join(tmpDir, dir[0].Name())
+		return nil, "Webapp-only plugin: the bundle is published as-is and covers all platforms.", nil
 	}
 
-	manifest, _, err := model.FindManifest(tmpDir)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to find manifest")
-	}
+	if len(manifest.Server.Executables) == 0 {
+		if manifest.Server.Executable == "" {
+			return nil, "", errors.New("no executables defined")
+		}
 
-	// We should probably support this as a platform-agnostic plugin, but leaving that existing
-	// gap to a future reader.
-	if manifest.Server == nil {
-		return nil, fmt.Errorf("no server defined")
-	}
+		executable, err := readPluginArchiveFile(filePath, manifest.Server.Executable)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to read singular executable")
+		}
 
-	if len(manifest.Server.Executables) == 0 {
-		if len(manifest.Server.Executable) > 0 {
-			return nil, fmt.Errorf("singular executable without defined platform not supported")
+		platforms, err := detectExecutablePlatforms(executable)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to detect the platform of %s", manifest.Server.Executable)
 		}
 
-		return nil, fmt.Errorf("no executables defined")
+		notice := fmt.Sprintf("Singular executable `%s`: the bundle is published as-is and only covers %s.", manifest.Server.Executable, strings.Join(platforms, ", "))
+		return nil, notice, nil
 	}
 
 	// Executables is a map from platform to the path within the plugin.tar.gz, but the caller
@@ -801,7 +778,7 @@ func findPlatformBinaries(filePath string) (map[string]string, error) {
 		foundBinaries[platform] = filepath.Base(pathToBinary)
 	}
 
-	return foundBinaries, nil
+	return foundBinaries, "", nil
 }
 
 // archiveContains returns filenames that matches a given string.
@@ -838,60 +815,24 @@ func archiveContains(filePath string, contains string) ([]string, error) {
 	return result, nil
 }
 
-// getSSHClientConfig Loads a private and public key from "path" and returns a SSH ClientConfig to authenticate with the server.
-func getSSHClientConfig(username, path, certPath, hostPublicKey string) (*ssh.ClientConfig, error) {
-	privateKey, err := os.ReadFile(path)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read key path")
-	}
-
-	signer, err := ssh.ParsePrivateKey(privateKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse private key")
-	}
-
-	// Load the certificate if present
-	if certPath != "" {
-		var cert []byte
-		cert, err = os.ReadFile(certPath)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to read cert path")
-		}
-
-		var pk ssh.PublicKey
-		pk, _, _, _, err = ssh.ParseAuthorizedKey(cert)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse authorized key")
-		}
+// getSuccessMessage return the plugin release success message to get posted into a channel.
+// releaseURL, commitSHA and marketplacePRURL may be empty. Without marketplacePRURL, the message
+// explains how to add the release to the Marketplace.
+func getSuccessMessage(tag, repo, commitSHA, releaseURL, marketplacePRURL, username string) string {
+	msg := fmt.Sprintf("@%s A Plugin was successfully signed and uploaded to Github and S3.\nTag: **%s**\nRepo: **%s**\n", username, tag, repo)
 
-		signer, err = ssh.NewCertSigner(pk.(*ssh.Certificate), signer)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get cert signer")
-		}
+	if commitSHA != "" {
+		msg += fmt.Sprintf("CommitSHA: **%s**\n", commitSHA)
 	}
 
-	if hostPublicKey == "" {
-		return nil, errors.New("missing host public key")
+	if releaseURL != "" {
+		msg += fmt.Sprintf("[Release Link](%s)\n", releaseURL)
 	}
 
-	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostPublicKey))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed parse host public key")
+	if marketplacePRURL != "" {
+		return msg + fmt.Sprintf("[Marketplace Pull Request](%s)", marketplacePRURL)
 	}
 
-	return &ssh.ClientConfig{
-		User: username,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.FixedHostKey(hostKey),
-		Timeout:         30 * time.Second,
-	}, nil
-}
-
-// getSuccessMessage return the plugin release success message to get posted into a channel.
-// releaseURL and commitSHA may be empty.
-func getSuccessMessage(tag, repo, commitSHA, releaseURL, username string) string {
 	branch := fmt.Sprintf("add_%s_%s", repo, tag)
 
 	const codeSeperator = "```"
@@ -921,16 +862,6 @@ git checkout master
 		branch,
 	)
 
-	msg := fmt.Sprintf("@%s A Plugin was successfully signed and uploaded to Github and S3.\nTag: **%s**\nRepo: **%s**\n", username, tag, repo)
-
-	if commitSHA != "" {
-		msg += fmt.Sprintf("CommitSHA: **%s**\n", commitSHA)
-	}
-
-	if releaseURL != "" {
-		msg += fmt.Sprintf("[Release Link](%s)\n", releaseURL)
-	}
-
 	msg += fmt.Sprintf(
 		"To add this release to the Plugin Marketplace run inside your local Marketplace repository:%sUse %s to open a Pull Request.",
 		marketplaceCommand, url,
@@ -6,10 +6,17 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -17,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
 
 	"github.com/mattermost/matterbuild/server/mocks"
 )
@@ -27,7 +35,7 @@ func TestCreatePlatformPlugins(t *testing.T) {
 		require.NoError(t, err)
 		defer os.RemoveAll(tmpFolder)
 
-		platformPluginFilePaths, err := createPlatformPlugins("myrepo", "mytag", "invalid", tmpFolder)
+		platformPluginFilePaths, _, err := createPlatformPlugins("myrepo", "mytag", "invalid", tmpFolder)
 		require.Error(t, err)
 		require.Nil(t, platformPluginFilePaths)
 	})
@@ -44,7 +52,7 @@ func TestCreatePlatformPlugins(t *testing.T) {
 			"myrepo-mytag-windows-amd64.tar.gz": "plugin-windows-amd64.exe",
 			"myrepo-mytag-linux-amd64.tar.gz":   "plugin-linux-amd64",
 		}
-		platformPluginFilePaths, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
+		platformPluginFilePaths, _, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
 		require.NoError(t, err)
 		require.Len(t, platformPluginFilePaths, 3)
 
@@ -71,7 +79,7 @@ func TestCreatePlatformPlugins(t *testing.T) {
 		expectedFiles := map[string]string{
 			"myrepo-mytag-linux-amd64.tar.gz": "plugin-linux-amd64",
 		}
-		platformPluginFilePaths, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
+		platformPluginFilePaths, _, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
 		require.NoError(t, err)
 		require.Len(t, platformPluginFilePaths, 1)
 
@@ -100,7 +108,7 @@ func TestCreatePlatformPlugins(t *testing.T) {
 			"myrepo-mytag-windows-amd64.tar.gz": "plugin-windows-amd64.exe",
 			"myrepo-mytag-linux-amd64.tar.gz":   "plugin-linux-amd64",
 		}
-		platformPluginFilePaths, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
+		platformPluginFilePaths, _, err := createPlatformPlugins("myrepo", "mytag", path, tmpFolder)
 		require.NoError(t, err)
 		require.Len(t, platformPluginFilePaths, 3)
 
@@ -128,7 +136,7 @@ func TestCreatePlatformPlugins(t *testing.T) {
 			"mattermost-plugin-calls-mytag-linux-amd64.tar.gz":   "plugin-linux-amd64",
 			"mattermost-plugin-calls-mytag-freebsd-amd64.tar.gz": "plugin-freebsd-amd64",
 		}
-		platformPluginFilePaths, err := createPlatformPlugins("mattermost-plugin-calls", "mytag", path, tmpFolder)
+		platformPluginFilePaths, _, err := createPlatformPlugins("mattermost-plugin-calls", "mytag", path, tmpFolder)
 		require.NoError(t, err)
 		require.Len(t, platformPluginFilePaths, 2)
 
@@ -187,205 +195,165 @@ func TestArchiveContains(t *testing.T) {
 
 func TestFindlatformBinaries(t *testing.T) {
 	t.Run("invalid archive file", func(t *testing.T) {
-		platformBinaries, err := findPlatformBinaries("invalid")
+		platformBinaries, _, err := findPlatformBinaries("invalid")
 		require.Error(t, err)
 		require.Empty(t, platformBinaries)
 	})
 
 	t.Run("missing two platform binaries", func(t *testing.T) {
-		platformBinaries, err := findPlatformBinaries(filepath.Join("test", "mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz"))
+		platformBinaries, notice, err := findPlatformBinaries(filepath.Join("test", "mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz"))
 		require.NoError(t, err)
+		require.Empty(t, notice)
 		require.Equal(t, map[string]string{
 			"linux-amd64": "plugin-linux-amd64",
 		}, platformBinaries)
 	})
 
 	t.Run("contains all platform binaries", func(t *testing.T) {
-		platformBinaries, err := findPlatformBinaries(filepath.Join("test", "mattermost-plugin-demo-v0.4.1.tar.gz"))
+		platformBinaries, notice, err := findPlatformBinaries(filepath.Join("test", "mattermost-plugin-demo-v0.4.1.tar.gz"))
 		require.NoError(t, err)
+		require.Empty(t, notice)
 		require.Equal(t, map[string]string{
 			"darwin-amd64":  "plugin-darwin-amd64",
 			"windows-amd64": "plugin-windows-amd64.exe",
 			"linux-amd64":   "plugin-linux-amd64",
 		}, platformBinaries)
 	})
-}
-
-func TestCreateTag(t *testing.T) {
-	t.Run("create tag using master's tip", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		ctx := context.Background()
-
-		gitMock := mocks.NewMockGithubGitService(ctrl)
-		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
-		owner := "owner"
-		repoName := "repoName"
-		tag := "testTag"
-		commitSHA := ""
-
-		testClient := &GithubClient{
-			Git:          gitMock,
-			Repositories: repoMock,
-		}
-		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(nil, nil, nil)
-
-		repo := &github.Repository{
-			DefaultBranch: github.String("master"),
-		}
-		repoMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName)).Return(repo, nil, nil)
 
-		masterRef := &github.Reference{
-			Object: &github.GitObject{
-				SHA: github.String("master-SHA"),
-			},
-		}
-		gitMock.EXPECT().GetRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq("heads/master")).Return(masterRef, nil, nil)
-
-		githubObj := &github.GitObject{
-			SHA:  masterRef.Object.SHA,
-			Type: github.String("commit"),
-		}
-		githubTag := &github.Tag{
-			Tag:     github.String(tag),
-			Message: github.String(tag),
-			Object:  githubObj,
-		}
-		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(nil, nil, nil)
+	t.Run("webapp-only plugin", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.webapp", "webapp": {"bundle_path": "webapp/dist/main.js"}}`},
+			{Name: "plugin/webapp/dist/main.js", Body: "console.log()"},
+		})
 
-		refTag := &github.Reference{
-			Ref:    github.String(fmt.Sprintf("tags/%s", tag)),
-			Object: githubObj,
-		}
-		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
-
-		err := createTag(ctx, testClient, owner, repoName, tag, commitSHA)
+		platformBinaries, notice, err := findPlatformBinaries(archivePath)
 		require.NoError(t, err)
+		require.Empty(t, platformBinaries)
+		require.Equal(t, "Webapp-only plugin: the bundle is published as-is and covers all platforms.", notice)
 	})
 
-	t.Run("create tag using given commit SHA", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		ctx := context.Background()
+	t.Run("singular executable", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.server", "server": {"executable": "server/dist/plugin"}}`},
+			{Name: "plugin/server/dist/plugin", Body: string(testELFHeader(elf.EM_X86_64)), Mode: 0755},
+		})
 
-		gitMock := mocks.NewMockGithubGitService(ctrl)
-		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
-		owner := "owner"
-		repoName := "repoName"
-		tag := "testTag"
-		commitSHA := "sha"
+		platformBinaries, notice, err := findPlatformBinaries(archivePath)
+		require.NoError(t, err)
+		require.Empty(t, platformBinaries)
+		require.Equal(t, "Singular executable `server/dist/plugin`: the bundle is published as-is and only covers linux-amd64.", notice)
+	})
 
-		testClient := &GithubClient{
-			Git:          gitMock,
-			Repositories: repoMock,
-		}
-		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(nil, nil, nil)
+	t.Run("singular executable of unknown format", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.server", "server": {"executable": "server/dist/plugin"}}`},
+			{Name: "plugin/server/dist/plugin", Body: "#!/bin/sh", Mode: 0755},
+		})
 
-		githubObj := &github.GitObject{
-			SHA:  github.String(commitSHA),
-			Type: github.String("commit"),
-		}
-		githubTag := &github.Tag{
-			Tag:     github.String(tag),
-			Message: github.String(tag),
-			Object:  githubObj,
-		}
-		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(nil, nil, nil)
-		repoMock.EXPECT().GetCommit(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(commitSHA)).Return(nil, nil, nil)
+		_, _, err := findPlatformBinaries(archivePath)
+		require.Error(t, err)
+	})
 
-		refTag := &github.Reference{
-			Ref:    github.String(fmt.Sprintf("tags/%s", tag)),
-			Object: githubObj,
-		}
-		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
+	t.Run("missing singular executable", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.server", "server": {"executable": "server/dist/plugin"}}`},
+		})
 
-		err := createTag(ctx, testClient, owner, repoName, tag, commitSHA)
-		require.NoError(t, err)
+		_, _, err := findPlatformBinaries(archivePath)
+		require.Error(t, err)
 	})
 
-	t.Run("create tag that returns other matching tags", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		ctx := context.Background()
+	t.Run("server without executables", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.server", "server": {}}`},
+		})
 
-		gitMock := mocks.NewMockGithubGitService(ctrl)
-		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
-		owner := "owner"
-		repoName := "repoName"
-		tag := "testTag"
-		commitSHA := "sha"
+		_, _, err := findPlatformBinaries(archivePath)
+		require.EqualError(t, err, "no executables defined")
+	})
 
-		testClient := &GithubClient{
-			Git:          gitMock,
-			Repositories: repoMock,
-		}
-		refs := []*github.Reference{
-			{
-				Ref: github.String("tags/testTag-1"),
-			},
-			{
-				Ref: github.String("tags/testTag-2"),
-			},
-		}
+	t.Run("neither server nor webapp", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, archivePath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.empty"}`},
+		})
 
-		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(refs, nil, nil)
+		_, _, err := findPlatformBinaries(archivePath)
+		require.EqualError(t, err, "neither server nor webapp defined")
+	})
+}
 
-		githubObj := &github.GitObject{
-			SHA:  github.String(commitSHA),
-			Type: github.String("commit"),
-		}
-		githubTag := &github.Tag{
-			Tag:     github.String(tag),
-			Message: github.String(tag),
-			Object:  githubObj,
-		}
-		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(nil, nil, nil)
-		repoMock.EXPECT().GetCommit(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(commitSHA)).Return(nil, nil, nil)
+func TestCheckRepo(t *testing.T) {
+	ctx := context.Background()
+	owner := "mattermost"
+
+	setup := func(t *testing.T, repo string, repository *github.Repository, err error) *GithubClient {
+		repoMock := mocks.NewMockGithubRepositoriesService(gomock.NewController(t))
+		repoMock.EXPECT().Get(gomock.Eq(ctx), owner, repo).Return(repository, nil, err)
+		return &GithubClient{Repositories: repoMock}
+	}
+	repository := &github.Repository{
+		Name:     github.String("mattermost-plugin-demo"),
+		FullName: github.String("mattermost/mattermost-plugin-demo"),
+		Owner:    &github.User{Login: github.String("mattermost")},
+		Topics:   []string{"mattermost", "mattermost-plugin"},
+	}
+
+	t.Run("allowed repository", func(t *testing.T) {
+		cfg := &MatterbuildConfig{
+			PluginRepositories:     []string{"mattermost-plugin-*"},
+			PluginRepositoryTopics: []string{"mattermost-plugin"},
+		}
+		client := setup(t, "mattermost-plugin-demo", repository, nil)
+		require.NoError(t, checkRepo(ctx, cfg, client, owner, "mattermost-plugin-demo"))
+	})
 
-		refTag := &github.Reference{
-			Ref:    github.String(fmt.Sprintf("tags/%s", tag)),
-			Object: githubObj,
+	t.Run("allow and deny lists", func(t *testing.T) {
+		cfg := &MatterbuildConfig{
+			PluginRepositories:       []string{"mattermost-plugin-*"},
+			PluginRepositoriesDenied: []string{"mattermost-plugin-demo"},
 		}
-		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
+		err := checkRepo(ctx, cfg, &GithubClient{}, owner, "Mattermost-Plugin-Demo")
+		require.EqualError(t, err, "repository Mattermost-Plugin-Demo is not allowed to be released by matterbuild")
 
-		err := createTag(ctx, testClient, owner, repoName, tag, commitSHA)
-		require.NoError(t, err)
+		err = checkRepo(ctx, cfg, &GithubClient{}, owner, "mattermost-server")
+		require.EqualError(t, err, "repository mattermost-server is not allowed to be released by matterbuild")
 	})
 
-	t.Run("create tag that returns matching tags", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		ctx := context.Background()
-
-		gitMock := mocks.NewMockGithubGitService(ctrl)
-		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
-		owner := "owner"
-		repoName := "repoName"
-		tag := "testTag"
-		commitSHA := "sha"
+	t.Run("missing repository", func(t *testing.T) {
+		notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+		client := setup(t, "mattermost-plugin-missing", nil, notFound)
+		err := checkRepo(ctx, &MatterbuildConfig{}, client, owner, "mattermost-plugin-missing")
+		require.EqualError(t, err, "looks like this repository is not part of the org or does not exist. Repo: mattermost-plugin-missing")
+	})
 
-		testClient := &GithubClient{
-			Git:          gitMock,
-			Repositories: repoMock,
-		}
-		refs := []*github.Reference{
-			{
-				Ref: github.String("tags/testTag-1"),
-			},
-			{
-				Ref: github.String("tags/testTag-2"),
-			},
-			{
-				Ref: github.String("tags/testTag"),
-			},
-		}
+	t.Run("moved repository", func(t *testing.T) {
+		client := setup(t, "mattermost-plugin-old", repository, nil)
+		err := checkRepo(ctx, &MatterbuildConfig{}, client, owner, "mattermost-plugin-old")
+		require.EqualError(t, err, "repository mattermost/mattermost-plugin-old was moved to mattermost/mattermost-plugin-demo, use its new name")
+	})
 
-		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(refs, nil, nil)
+	t.Run("archived repository", func(t *testing.T) {
+		archived := *repository
+		archived.Archived = github.Bool(true)
+		client := setup(t, "mattermost-plugin-demo", &archived, nil)
+		err := checkRepo(ctx, &MatterbuildConfig{}, client, owner, "mattermost-plugin-demo")
+		require.EqualError(t, err, "repository mattermost-plugin-demo is archived")
+	})
 
-		err := createTag(ctx, testClient, owner, repoName, tag, commitSHA)
-		require.Error(t, err)
-		require.True(t, errors.Is(err, ErrTagExists))
+	t.Run("missing topic", func(t *testing.T) {
+		client := setup(t, "mattermost-plugin-demo", repository, nil)
+		err := checkRepo(ctx, &MatterbuildConfig{PluginRepositoryTopics: []string{"mattermost-plugin", "official"}}, client, owner, "mattermost-plugin-demo")
+		require.EqualError(t, err, "repository mattermost-plugin-demo is missing the topic official")
 	})
 }
 
-func TestGetPluginAsset(t *testing.T) {
-	ctx := context.Background()
+func TestFindPluginAsset(t *testing.T) {
 	release := &github.RepositoryRelease{}
 
 	t.Run("should find the tarball if only one exists", func(t *testing.T) {
@@ -394,7 +362,7 @@ func TestGetPluginAsset(t *testing.T) {
 			{ID: github.Int64(2), Name: github.String("tarball.tar.gz")},
 		}
 
-		asset, err := getPluginAsset(ctx, release, "")
+		asset, err := findPluginAsset(release, "")
 		require.NoError(t, err)
 		require.Equal(t, github.String("tarball.tar.gz"), asset.Name)
 	})
@@ -405,7 +373,7 @@ func TestGetPluginAsset(t *testing.T) {
 			{ID: github.Int64(2), Name: github.String("plugin-tarball.tar.gz")},
 		}
 
-		asset, err := getPluginAsset(ctx, release, "")
+		asset, err := findPluginAsset(release, "")
 		require.EqualError(t, err, "found unexpected file plugin-tarball.tar.gz")
 		require.Nil(t, asset)
 	})
@@ -416,10 +384,74 @@ func TestGetPluginAsset(t *testing.T) {
 			{ID: github.Int64(2), Name: github.String("plugin-tarball.tar.gz")},
 		}
 
-		asset, err := getPluginAsset(ctx, release, "plugin-tarball.tar.gz")
+		asset, err := findPluginAsset(release, "plugin-tarball.tar.gz")
 		require.NoError(t, err)
 		require.Equal(t, github.String("plugin-tarball.tar.gz"), asset.Name)
 	})
+
+	t.Run("should return nil if the tarball is not uploaded yet", func(t *testing.T) {
+		release.Assets = []github.ReleaseAsset{
+			{ID: github.Int64(1), Name: github.String("README.txt")},
+		}
+
+		asset, err := findPluginAsset(release, "")
+		require.NoError(t, err)
+		require.Nil(t, asset)
+	})
+}
+
+func TestWaitForPluginAsset(t *testing.T) {
+	ctx := context.Background()
+	owner := "owner"
+	repoName := "repoName"
+	tag := "v1.0.0"
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: &http.Request{}}}
+	cfg := &MatterbuildConfig{GithubWebhookSecret: "secret"}
+
+	t.Run("release events wake the wait", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+
+		gomock.InOrder(
+			repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).
+				DoAndReturn(func(context.Context, string, string, string) (*github.RepositoryRelease, *github.Response, error) {
+					go pluginReleaseNotifier.notify("Owner", "RepoName")
+					return nil, nil, notFound
+				}),
+			repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).
+				DoAndReturn(func(context.Context, string, string, string) (*github.RepositoryRelease, *github.Response, error) {
+					go pluginReleaseNotifier.notify(owner, repoName)
+					return &github.RepositoryRelease{}, nil, nil
+				}),
+			repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).
+				Return(&github.RepositoryRelease{Assets: []github.ReleaseAsset{{Name: github.String("plugin.tar.gz")}}}, nil, nil),
+		)
+
+		asset, err := waitForPluginAsset(ctx, cfg, &GithubClient{Repositories: repoMock}, owner, repoName, tag, "")
+		require.NoError(t, err)
+		require.Equal(t, "plugin.tar.gz", asset.GetName())
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(nil, nil, notFound)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := waitForPluginAsset(ctx, cfg, &GithubClient{Repositories: repoMock}, owner, repoName, tag, "")
+		require.EqualError(t, err, "timed out waiting for the release asset of v1.0.0")
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(nil, nil, errors.New("rate limited"))
+
+		_, err := waitForPluginAsset(ctx, cfg, &GithubClient{Repositories: repoMock}, owner, repoName, tag, "")
+		require.EqualError(t, err, "failed to get release by tag: rate limited")
+	})
 }
 
 func TestDownloadAsset(t *testing.T) {
@@ -489,7 +521,7 @@ func TestGetSuccessMessage(t *testing.T) {
 	releaseURL := "https://github.com/mattermost/mattermost-plugin-jira/releases/tag/v3.0.0"
 	username := "foo"
 
-	actualMessage := getSuccessMessage(tag, repo, commitSHA, releaseURL, username)
+	actualMessage := getSuccessMessage(tag, repo, commitSHA, releaseURL, "", username)
 	expectedMessage := `@foo A Plugin was successfully signed and uploaded to Github and S3.
 Tag: **v3.0.0**
 Repo: **mattermost-plugin-jira**
@@ -510,6 +542,14 @@ git checkout master` + "\n```\n" +
 		`Use https://github.com/mattermost/mattermost-marketplace/compare/production...add_mattermost-plugin-jira_v3.0.0?quick_pull=1&labels=3:+QA+Review,2:+Dev+Review to open a Pull Request.`
 
 	assert.Equal(t, expectedMessage, actualMessage)
+
+	actualMessage = getSuccessMessage(tag, repo, "", "", "https://github.com/mattermost/mattermost-marketplace/pull/42", username)
+	expectedMessage = `@foo A Plugin was successfully signed and uploaded to Github and S3.
+Tag: **v3.0.0**
+Repo: **mattermost-plugin-jira**
+[Marketplace Pull Request](https://github.com/mattermost/mattermost-marketplace/pull/42)`
+
+	assert.Equal(t, expectedMessage, actualMessage)
 }
 
 func TestMarkTagAsPreRelease(t *testing.T) {
@@ -580,3 +620,157 @@ func TestMarkTagAsPreRelease(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+// startTestS3Server starts a fake S3 server for the bucket named bucket, keeping the objects by key.
+func startTestS3Server(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+
+	objects := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+
+		switch r.Method {
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			objects[key] = data
+			w.Header().Set("ETag", `"etag"`)
+		case http.MethodGet:
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+				return
+			}
+			w.Write(data)
+		case http.MethodHead:
+			if _, ok := objects[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected S3 request %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, objects
+}
+
+func TestCutPlugin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	owner := "owner"
+	repoName := "mattermost-plugin-demo"
+	tag := "v0.4.1"
+	var releaseID int64 = 42
+	asset := github.ReleaseAsset{ID: github.Int64(5), Name: github.String("mattermost-plugin-demo-v0.4.1.tar.gz")}
+	release := &github.RepositoryRelease{ID: &releaseID, Assets: []github.ReleaseAsset{asset}}
+
+	dir := t.TempDir()
+	privateKeyPath, publicKeyPath, _ := writeTestSigningKey(t, dir)
+
+	s3URL, s3Objects := startTestS3Server(t)
+
+	cfg := &MatterbuildConfig{
+		PluginSigningBackend:           "local",
+		PluginSigningPublicKeyPath:     publicKeyPath,
+		PluginSigningPrivateKeyPath:    privateKeyPath,
+		PluginSigningAWSAccessKey:      "access",
+		PluginSigningAWSSecretKey:      "secret",
+		PluginSigningAWSRegion:         "us-east-1",
+		PluginSigningAWSS3PluginBucket: "bucket",
+		PluginSigningAWSS3Endpoint:     s3URL,
+	}
+
+	pluginFilePath := filepath.Join(dir, asset.GetName())
+	writeTestPlugin(t, pluginFilePath, "0.4.1")
+	pluginFile, err := os.Open(pluginFilePath)
+	require.NoError(t, err)
+	defer pluginFile.Close()
+
+	repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+	testClient := &GithubClient{
+		Repositories: repoMock,
+	}
+
+	githubUploads := map[string][]byte{}
+	repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(release, nil, nil).AnyTimes()
+	repoMock.EXPECT().DownloadReleaseAsset(gomock.Any(), owner, repoName, asset.GetID()).Return(pluginFile, "", nil)
+	repoMock.EXPECT().ListReleaseAssets(gomock.Any(), owner, repoName, releaseID, nil).Return(nil, nil, nil).AnyTimes()
+	repoMock.EXPECT().UploadReleaseAsset(gomock.Any(), owner, repoName, releaseID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, _ int64, opts *github.UploadOptions, file *os.File) (*github.ReleaseAsset, *github.Response, error) {
+			data, err := io.ReadAll(file)
+			require.NoError(t, err)
+			githubUploads[opts.Name] = data
+			return &github.ReleaseAsset{}, nil, nil
+		}).Times(5)
+
+	result, err := cutPlugin(ctx, cfg, testClient, owner, repoName, tag, "", false, true)
+	require.NoError(t, err)
+	require.Empty(t, result.Notice)
+	require.ElementsMatch(t, []string{
+		"mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz",
+		"mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz",
+		"mattermost-plugin-demo-v0.4.1-windows-amd64.tar.gz",
+	}, result.PlatformPluginFiles)
+
+	keyring, err := loadPluginPublicKeyring(cfg)
+	require.NoError(t, err)
+
+	pluginData, err := os.ReadFile(pluginFilePath)
+	require.NoError(t, err)
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(pluginData), bytes.NewReader(githubUploads[asset.GetName()+".sig"]))
+	require.NoError(t, err)
+
+	expectedKeys := []string{
+		"release/mattermost-plugin-demo-v0.4.1.tar.gz",
+		"release/mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz",
+		"release/mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz",
+		"release/mattermost-plugin-demo-v0.4.1-windows-amd64.tar.gz",
+	}
+	require.Len(t, s3Objects, 2*len(expectedKeys)+4)
+	for _, key := range expectedKeys {
+		require.Contains(t, s3Objects, key)
+		require.Contains(t, s3Objects, key+".sig")
+
+		_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(s3Objects[key]), bytes.NewReader(s3Objects[key+".sig"]))
+		require.NoError(t, err, key)
+	}
+	require.Equal(t, pluginData, s3Objects["release/mattermost-plugin-demo-v0.4.1.tar.gz"])
+
+	require.NotNil(t, result.Marketplace)
+	require.Equal(t, "com.mattermost.demo-plugin", result.Marketplace.Manifest.Id)
+	require.Equal(t, "https://plugins.releases.mattermost.com/release/mattermost-plugin-demo-v0.4.1.tar.gz", result.Marketplace.DownloadURL)
+	require.Equal(t, base64.StdEncoding.EncodeToString(s3Objects["release/mattermost-plugin-demo-v0.4.1.tar.gz.sig"]), result.Marketplace.Signature)
+	require.Len(t, result.Marketplace.Platforms, 3)
+	require.Equal(t, "https://plugins.releases.mattermost.com/release/mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz", result.Marketplace.Platforms["linux-amd64"].DownloadURL)
+
+	sums := githubUploads["mattermost-plugin-demo-v0.4.1-SHA256SUMS"]
+	require.Equal(t, result.Checksums, string(sums))
+	require.Equal(t, sums, s3Objects["release/mattermost-plugin-demo-v0.4.1-SHA256SUMS"])
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(sums), bytes.NewReader(githubUploads["mattermost-plugin-demo-v0.4.1-SHA256SUMS.sig"]))
+	require.NoError(t, err)
+
+	var manifest pluginReleaseManifest
+	manifestData := githubUploads["mattermost-plugin-demo-v0.4.1-manifest.json"]
+	require.Equal(t, manifestData, s3Objects["release/mattermost-plugin-demo-v0.4.1-manifest.json"])
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(manifestData), bytes.NewReader(githubUploads["mattermost-plugin-demo-v0.4.1-manifest.json.sig"]))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(manifestData, &manifest))
+	require.Equal(t, repoName, manifest.Repository)
+	require.Equal(t, tag, manifest.Tag)
+	require.Len(t, manifest.Artifacts, len(expectedKeys))
+	for _, artifact := range manifest.Artifacts {
+		data := s3Objects["release/"+artifact.Name]
+		require.NotNil(t, data, artifact.Name)
+		require.Equal(t, int64(len(data)), artifact.Size)
+		require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(data)), artifact.SHA256)
+		require.Equal(t, artifact.Name+".sig", artifact.Signature)
+		require.NotEmpty(t, artifact.SignatureKeyID)
+		require.Contains(t, string(sums), artifact.SHA256+"  "+artifact.Name+"\n")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	pluginStatusGithub = "GitHub"
+	pluginStatusS3     = "S3"
+)
+
+// pluginStatusEntry is the status of a file of a plugin release.
+type pluginStatusEntry struct {
+	Location string // pluginStatusGithub or pluginStatusS3
+	Name     string
+	KeyID    string // ID of the key the file is signed with, once verified
+	Problem  string // Empty if the file is published as expected
+}
+
+// pluginReleaseStatus compares what is published for a plugin release on GitHub and S3 with
+// what cutPlugin publishes.
+type pluginReleaseStatus struct {
+	Repository string
+	Tag        string
+	ReleaseURL string
+	Entries    []*pluginStatusEntry
+}
+
+func (s *pluginReleaseStatus) add(location, name, keyID, problem string) {
+	s.Entries = append(s.Entries, &pluginStatusEntry{Location: location, Name: name, KeyID: keyID, Problem: problem})
+}
+
+// problems returns the entries that are not published as expected.
+func (s *pluginReleaseStatus) problems() []*pluginStatusEntry {
+	var problems []*pluginStatusEntry
+	for _, entry := range s.Entries {
+		if entry.Problem != "" {
+			problems = append(problems, entry)
+		}
+	}
+
+	return problems
+}
+
+// message formats the status to get posted into a channel.
+func (s *pluginReleaseStatus) message() string {
+	msg := fmt.Sprintf("Status of **%s** [%s](%s):\n", s.Repository, s.Tag, s.ReleaseURL)
+
+	for _, location := range []string{pluginStatusGithub, pluginStatusS3} {
+		msg += fmt.Sprintf("\n#### %s\n", location)
+		for _, entry := range s.Entries {
+			if entry.Location != location {
+				continue
+			}
+
+			status := ":white_check_mark:"
+			if entry.KeyID != "" {
+				status += " signed by " + entry.KeyID
+			}
+			if entry.Problem != "" {
+				status = ":x: " + entry.Problem
+			}
+			msg += fmt.Sprintf("* `%s`: %s\n", entry.Name, status)
+		}
+	}
+
+	if problems := s.problems(); len(problems) > 0 {
+		msg += fmt.Sprintf("\n%d problem(s) found. Run `/matterbuild cutplugin --tag %s --repo %s --force` to publish the release again.", len(problems), s.Tag, s.Repository)
+	} else {
+		msg += "\nThe release is fully published."
+	}
+
+	return msg
+}
+
+// getPluginReleaseStatus checks that the plugin tar of the release and its signature, the
+// platform specific tars, and the checksums and manifest files are published on GitHub and S3,
+// with valid signatures. The platform tars must match the ones rebuilt from the GitHub release
+// asset, and the checksums and manifest files the GitHub ones. tag defaults to the latest release.
+func getPluginReleaseStatus(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repositoryName, tag string) (*pluginReleaseStatus, error) {
+	var release *github.RepositoryRelease
+	var err error
+	if tag == "" {
+		release, _, err = client.Repositories.GetLatestRelease(ctx, owner, repositoryName)
+	} else {
+		release, _, err = client.Repositories.GetReleaseByTag(ctx, owner, repositoryName, tag)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get release")
+	}
+	tag = release.GetTagName()
+
+	keyring, err := loadPluginPublicKeyring(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load public keyring")
+	}
+
+	tmpFolder, err := os.MkdirTemp("", "plugin-status")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpFolder)
+
+	githubFolder := filepath.Join(tmpFolder, "github")
+	s3Folder := filepath.Join(tmpFolder, "s3")
+	rebuiltFolder := filepath.Join(tmpFolder, "rebuilt")
+	for _, folder := range []string{githubFolder, s3Folder, rebuiltFolder} {
+		if err = os.Mkdir(folder, 0700); err != nil {
+			return nil, errors.Wrap(err, "failed to create temp dir")
+		}
+	}
+
+	status := &pluginReleaseStatus{
+		Repository: repositoryName,
+		Tag:        tag,
+		ReleaseURL: release.GetHTMLURL(),
+	}
+	prefix := fmt.Sprintf("%s-%s", repositoryName, tag)
+	releaseManifestFiles := []string{prefix + "-SHA256SUMS", prefix + "-manifest.json"}
+
+	// GitHub release
+	githubAssets := make(map[string]*github.ReleaseAsset)
+	var pluginAsset *github.ReleaseAsset
+	for i := range release.Assets {
+		asset := &release.Assets[i]
+		githubAssets[asset.GetName()] = asset
+		if strings.HasSuffix(asset.GetName(), ".tar.gz") {
+			if pluginAsset != nil {
+				return nil, errors.Errorf("found more than one plugin tar in the release: %s and %s", pluginAsset.GetName(), asset.GetName())
+			}
+			pluginAsset = asset
+		}
+	}
+	if pluginAsset == nil {
+		status.add(pluginStatusGithub, "*.tar.gz", "", "missing, the plugin tar should be attached to the release")
+		return status, nil
+	}
+
+	githubPluginFilePath, err := downloadAsset(ctx, client, owner, repositoryName, pluginAsset, githubFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	if signatureAsset, ok := githubAssets[pluginAsset.GetName()+".sig"]; !ok {
+		status.add(pluginStatusGithub, pluginAsset.GetName(), "", "signature missing")
+	} else if _, err = downloadAsset(ctx, client, owner, repositoryName, signatureAsset, githubFolder); err != nil {
+		return nil, err
+	} else {
+		keyID, verifyErr := verifySignature(keyring, githubPluginFilePath)
+		status.add(pluginStatusGithub, pluginAsset.GetName(), keyID, verifyProblem(verifyErr))
+	}
+
+	githubChecksums := make(map[string]string)
+	for _, name := range releaseManifestFiles {
+		asset, ok := githubAssets[name]
+		if !ok {
+			status.add(pluginStatusGithub, name, "", "missing")
+			continue
+		}
+
+		filePath, err := downloadAsset(ctx, client, owner, repositoryName, asset, githubFolder)
+		if err != nil {
+			return nil, err
+		}
+		if githubChecksums[name], err = sha256File(filePath); err != nil {
+			return nil, err
+		}
+
+		if signatureAsset, ok := githubAssets[name+".sig"]; !ok {
+			status.add(pluginStatusGithub, name, "", "signature missing")
+		} else if _, err = downloadAsset(ctx, client, owner, repositoryName, signatureAsset, githubFolder); err != nil {
+			return nil, err
+		} else {
+			keyID, verifyErr := verifySignature(keyring, filePath)
+			status.add(pluginStatusGithub, name, keyID, verifyProblem(verifyErr))
+		}
+	}
+
+	// S3 release bucket, with the platform tars rebuilt from the GitHub release asset to
+	// compare them with
+	rebuiltPluginFilePaths, _, err := createPlatformPlugins(repositoryName, tag, githubPluginFilePath, rebuiltFolder)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to rebuild platform tars")
+	}
+	sort.Strings(rebuiltPluginFilePaths)
+
+	bundleName := prefix + ".tar.gz"
+	pluginNames := []string{bundleName}
+	for _, rebuiltPluginFilePath := range rebuiltPluginFilePaths {
+		pluginNames = append(pluginNames, filepath.Base(rebuiltPluginFilePath))
+	}
+
+	checksums := make(map[string]string)
+	for _, name := range pluginNames {
+		found, err := downloadFromS3(ctx, cfg, name, s3Folder)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			status.add(pluginStatusS3, name, "", "missing")
+			continue
+		}
+
+		if checksums[name], err = sha256File(filepath.Join(s3Folder, name)); err != nil {
+			return nil, err
+		}
+
+		found, err = downloadFromS3(ctx, cfg, name+".sig", s3Folder)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			status.add(pluginStatusS3, name, "", "signature missing")
+			continue
+		}
+
+		keyID, verifyErr := verifySignature(keyring, filepath.Join(s3Folder, name))
+		status.add(pluginStatusS3, name, keyID, verifyProblem(verifyErr))
+	}
+
+	// The bundle is published as-is
+	if githubChecksum, err := sha256File(githubPluginFilePath); err != nil {
+		return nil, err
+	} else if checksums[bundleName] != "" && checksums[bundleName] != githubChecksum {
+		status.add(pluginStatusS3, bundleName, "", "differs from the GitHub release asset "+pluginAsset.GetName())
+	}
+
+	// The platform tars are deterministic, so they must match the rebuilt ones byte for byte
+	for _, rebuiltPluginFilePath := range rebuiltPluginFilePaths {
+		name := filepath.Base(rebuiltPluginFilePath)
+		if rebuiltChecksum, err := sha256File(rebuiltPluginFilePath); err != nil {
+			return nil, err
+		} else if checksums[name] != "" && checksums[name] != rebuiltChecksum {
+			status.add(pluginStatusS3, name, "", "differs from the tar rebuilt from the GitHub release asset "+pluginAsset.GetName())
+		}
+	}
+
+	// The checksums and manifest files are published as-is
+	for _, name := range releaseManifestFiles {
+		found, err := downloadFromS3(ctx, cfg, name, s3Folder)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			status.add(pluginStatusS3, name, "", "missing")
+			continue
+		}
+
+		checksum, err := sha256File(filepath.Join(s3Folder, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if found, err = downloadFromS3(ctx, cfg, name+".sig", s3Folder); err != nil {
+			return nil, err
+		} else if !found {
+			status.add(pluginStatusS3, name, "", "signature missing")
+		} else {
+			keyID, verifyErr := verifySignature(keyring, filepath.Join(s3Folder, name))
+			status.add(pluginStatusS3, name, keyID, verifyProblem(verifyErr))
+		}
+
+		if githubChecksums[name] != "" && githubChecksums[name] != checksum {
+			status.add(pluginStatusS3, name, "", "differs from the GitHub release asset "+name)
+		}
+	}
+
+	// Compare with the release manifest, if any
+	manifestData, err := os.ReadFile(filepath.Join(s3Folder, prefix+"-manifest.json"))
+	if err != nil {
+		return status, nil
+	}
+
+	var manifest pluginReleaseManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		status.add(pluginStatusS3, prefix+"-manifest.json", "", "invalid: "+err.Error())
+		return status, nil
+	}
+	for _, artifact := range manifest.Artifacts {
+		if checksum, ok := checksums[artifact.Name]; ok && checksum != artifact.SHA256 {
+			status.add(pluginStatusS3, artifact.Name, "", "checksum does not match the release manifest")
+		}
+	}
+
+	return status, nil
+}
+
+func verifyProblem(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return "signature does not verify: " + err.Error()
+}
+
+// downloadFromS3 downloads the file with the given name from the release folder of the plugin
+// bucket into folder. Returns false if the file does not exist.
+func downloadFromS3(ctx context.Context, cfg *MatterbuildConfig, name, folder string) (bool, error) {
+	output, err := s3.New(newS3Session(cfg)).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(cfg.PluginSigningAWSS3PluginBucket),
+		Key:    aws.String("release/" + name),
+	})
+	if err != nil {
+		var requestErr awserr.RequestFailure
+		if errors.As(err, &requestErr) && requestErr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to download %s from s3", name)
+	}
+	defer output.Body.Close()
+
+	out, err := os.Create(filepath.Join(folder, name))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create file for %s", name)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, output.Body); err != nil {
+		return false, errors.Wrapf(err, "failed to download %s from s3", name)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestGetPluginReleaseStatus(t *testing.T) {
+	ctx := context.Background()
+	owner := "owner"
+	repoName := "mattermost-plugin-demo"
+	tag := "v0.4.1"
+
+	dir := t.TempDir()
+	privateKeyPath, publicKeyPath, keyID := writeTestSigningKey(t, dir)
+	s3URL, s3Objects := startTestS3Server(t)
+
+	cfg := &MatterbuildConfig{
+		PluginSigningBackend:           "local",
+		PluginSigningPublicKeyPath:     publicKeyPath,
+		PluginSigningPrivateKeyPath:    privateKeyPath,
+		PluginSigningAWSAccessKey:      "access",
+		PluginSigningAWSSecretKey:      "secret",
+		PluginSigningAWSRegion:         "us-east-1",
+		PluginSigningAWSS3PluginBucket: "bucket",
+		PluginSigningAWSS3Endpoint:     s3URL,
+	}
+
+	pluginName := "mattermost-plugin-demo-v0.4.1.tar.gz"
+	pluginFilePath := filepath.Join(dir, pluginName)
+	writeTestPlugin(t, pluginFilePath, "0.4.1")
+	pluginData, err := os.ReadFile(pluginFilePath)
+	require.NoError(t, err)
+
+	// Publish the release with cutPlugin, keeping the GitHub assets by name
+	githubAssets := map[string][]byte{pluginName: pluginData}
+	publish := func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		release := &github.RepositoryRelease{ID: github.Int64(42), Assets: []github.ReleaseAsset{{ID: github.Int64(1), Name: github.String(pluginName)}}}
+
+		repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(release, nil, nil).AnyTimes()
+		repoMock.EXPECT().DownloadReleaseAsset(gomock.Any(), owner, repoName, int64(1)).Return(io.NopCloser(bytes.NewReader(pluginData)), "", nil)
+		repoMock.EXPECT().ListReleaseAssets(gomock.Any(), owner, repoName, int64(42), nil).Return(nil, nil, nil).AnyTimes()
+		repoMock.EXPECT().UploadReleaseAsset(gomock.Any(), owner, repoName, int64(42), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, _ int64, opts *github.UploadOptions, file *os.File) (*github.ReleaseAsset, *github.Response, error) {
+				data, err := io.ReadAll(file)
+				require.NoError(t, err)
+				githubAssets[opts.Name] = data
+				return &github.ReleaseAsset{}, nil, nil
+			}).AnyTimes()
+
+		_, err := cutPlugin(ctx, cfg, &GithubClient{Repositories: repoMock}, owner, repoName, tag, "", false, false)
+		require.NoError(t, err)
+	}
+
+	getStatus := func(t *testing.T, tag string) *pluginReleaseStatus {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+
+		release := &github.RepositoryRelease{TagName: github.String("v0.4.1"), HTMLURL: github.String("https://github.com/owner/mattermost-plugin-demo/releases/tag/v0.4.1")}
+		var id int64
+		for name, data := range githubAssets {
+			id++
+			release.Assets = append(release.Assets, github.ReleaseAsset{ID: github.Int64(id), Name: github.String(name)})
+			repoMock.EXPECT().DownloadReleaseAsset(gomock.Any(), owner, repoName, id).Return(io.NopCloser(bytes.NewReader(data)), "", nil).AnyTimes()
+		}
+
+		if tag == "" {
+			repoMock.EXPECT().GetLatestRelease(gomock.Any(), owner, repoName).Return(release, nil, nil)
+		} else {
+			repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(release, nil, nil)
+		}
+
+		status, err := getPluginReleaseStatus(ctx, cfg, &GithubClient{Repositories: repoMock}, owner, repoName, tag)
+		require.NoError(t, err)
+		return status
+	}
+
+	t.Run("not published", func(t *testing.T) {
+		status := getStatus(t, tag)
+
+		var problems []string
+		for _, entry := range status.problems() {
+			problems = append(problems, entry.Location+" "+entry.Name+": "+entry.Problem)
+		}
+		require.Equal(t, []string{
+			"GitHub mattermost-plugin-demo-v0.4.1.tar.gz: signature missing",
+			"GitHub mattermost-plugin-demo-v0.4.1-SHA256SUMS: missing",
+			"GitHub mattermost-plugin-demo-v0.4.1-manifest.json: missing",
+			"S3 mattermost-plugin-demo-v0.4.1.tar.gz: missing",
+			"S3 mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz: missing",
+			"S3 mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz: missing",
+			"S3 mattermost-plugin-demo-v0.4.1-windows-amd64.tar.gz: missing",
+			"S3 mattermost-plugin-demo-v0.4.1-SHA256SUMS: missing",
+			"S3 mattermost-plugin-demo-v0.4.1-manifest.json: missing",
+		}, problems)
+		require.Contains(t, status.message(), "9 problem(s) found. Run `/matterbuild cutplugin --tag v0.4.1 --repo mattermost-plugin-demo --force`")
+	})
+
+	publish(t)
+
+	t.Run("fully published", func(t *testing.T) {
+		status := getStatus(t, "")
+		require.Equal(t, "v0.4.1", status.Tag)
+		require.Empty(t, status.problems())
+		require.Len(t, status.Entries, 9)
+		for _, entry := range status.Entries {
+			require.Equal(t, keyID, entry.KeyID, entry.Name)
+		}
+		require.Contains(t, status.message(), "* `mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz`: :white_check_mark: signed by "+keyID)
+		require.Contains(t, status.message(), "The release is fully published.")
+	})
+
+	t.Run("platform tar not rebuilt from the GitHub release asset", func(t *testing.T) {
+		darwin := "release/mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz"
+		published, publishedSignature := s3Objects[darwin], s3Objects[darwin+".sig"]
+		defer func() {
+			s3Objects[darwin], s3Objects[darwin+".sig"] = published, publishedSignature
+		}()
+
+		// Validly signed, but not what cutPlugin creates from the GitHub release asset
+		otherPluginFilePath := filepath.Join(t.TempDir(), "other.tar.gz")
+		writeTestPlugin(t, otherPluginFilePath, "0.4.2")
+		signer, err := newLocalSigner(privateKeyPath, "")
+		require.NoError(t, err)
+		require.NoError(t, signer.Sign(ctx, []string{otherPluginFilePath}))
+		s3Objects[darwin], err = os.ReadFile(otherPluginFilePath)
+		require.NoError(t, err)
+		s3Objects[darwin+".sig"], err = os.ReadFile(otherPluginFilePath + ".sig")
+		require.NoError(t, err)
+
+		status := getStatus(t, tag)
+
+		var problems []string
+		for _, entry := range status.problems() {
+			problems = append(problems, entry.Location+" "+entry.Name+": "+entry.Problem)
+		}
+		require.Equal(t, []string{
+			"S3 mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz: differs from the tar rebuilt from the GitHub release asset mattermost-plugin-demo-v0.4.1.tar.gz",
+			"S3 mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz: checksum does not match the release manifest",
+		}, problems)
+	})
+
+	t.Run("checksums and manifest files", func(t *testing.T) {
+		sums := "release/mattermost-plugin-demo-v0.4.1-SHA256SUMS"
+		publishedSums := s3Objects[sums]
+		manifestSignature := githubAssets["mattermost-plugin-demo-v0.4.1-manifest.json.sig"]
+		defer func() {
+			s3Objects[sums] = publishedSums
+			githubAssets["mattermost-plugin-demo-v0.4.1-manifest.json.sig"] = manifestSignature
+		}()
+
+		s3Objects[sums] = append(append([]byte{}, publishedSums...), '\n')
+		delete(githubAssets, "mattermost-plugin-demo-v0.4.1-manifest.json.sig")
+
+		status := getStatus(t, tag)
+
+		var problems []string
+		for _, entry := range status.problems() {
+			problems = append(problems, entry.Location+" "+entry.Name+": "+entry.Problem)
+		}
+		require.Len(t, problems, 3)
+		require.Equal(t, "GitHub mattermost-plugin-demo-v0.4.1-manifest.json: signature missing", problems[0])
+		require.Contains(t, problems[1], "S3 mattermost-plugin-demo-v0.4.1-SHA256SUMS: signature does not verify")
+		require.Equal(t, "S3 mattermost-plugin-demo-v0.4.1-SHA256SUMS: differs from the GitHub release asset mattermost-plugin-demo-v0.4.1-SHA256SUMS", problems[2])
+	})
+
+	t.Run("mismatched artifacts", func(t *testing.T) {
+		linux := "release/mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz"
+		s3Objects[linux] = append(s3Objects[linux], 0)
+		delete(s3Objects, "release/mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz.sig")
+		s3Objects["release/mattermost-plugin-demo-v0.4.1.tar.gz"] = s3Objects["release/mattermost-plugin-demo-v0.4.1-windows-amd64.tar.gz"]
+
+		status := getStatus(t, tag)
+
+		var problems []string
+		for _, entry := range status.problems() {
+			problems = append(problems, entry.Location+" "+entry.Name+": "+entry.Problem)
+		}
+		require.Len(t, problems, 7)
+		require.Contains(t, problems[0], "S3 mattermost-plugin-demo-v0.4.1.tar.gz: signature does not verify")
+		require.Equal(t, "S3 mattermost-plugin-demo-v0.4.1-darwin-amd64.tar.gz: signature missing", problems[1])
+		require.Contains(t, problems[2], "S3 mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz: signature does not verify")
+		require.Equal(t, "S3 mattermost-plugin-demo-v0.4.1.tar.gz: differs from the GitHub release asset mattermost-plugin-demo-v0.4.1.tar.gz", problems[3])
+		require.Equal(t, "S3 mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz: differs from the tar rebuilt from the GitHub release asset mattermost-plugin-demo-v0.4.1.tar.gz", problems[4])
+		require.ElementsMatch(t, []string{
+			"S3 mattermost-plugin-demo-v0.4.1.tar.gz: checksum does not match the release manifest",
+			"S3 mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz: checksum does not match the release manifest",
+		}, problems[5:])
+	})
+}
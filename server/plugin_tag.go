@@ -0,0 +1,207 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+var defaultPluginTagReleaseBranches = []string{"release-*"}
+
+// pluginTagRequest is the plugin tag being created, as available to PluginTagMessageTemplate.
+type pluginTagRequest struct {
+	Repository string
+	Tag        string
+	Username   string // User who requested the tag
+	Command    string // Slash command the tag was requested with
+}
+
+// createTag creates a new annotated tag at the given commit for the repository, after checking
+// the commit as configured. Returns ErrTagExists if tag already exists, nil if successful and an
+// error otherwise.
+func createTag(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, commitSHA string, request *pluginTagRequest) error {
+	repository, tag := request.Repository, request.Tag
+
+	tagRef := fmt.Sprintf("tags/%s", tag)
+	refs, _, err := client.Git.GetRefs(ctx, owner, repository, tagRef)
+	if err != nil {
+		var gerr *github.ErrorResponse
+		if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
+			LogInfo("tag %s was not found, creating tag", tag)
+		} else {
+			return errors.Wrapf(err, "failed to get github tag")
+		}
+	}
+	for _, ref := range refs {
+		if strings.HasSuffix(ref.GetRef(), tagRef) {
+			return ErrTagExists
+		}
+	}
+
+	message, err := pluginTagMessage(cfg, request)
+	if err != nil {
+		return err
+	}
+
+	if commitSHA == "" {
+		// Use the default branch's tip if commitSHA is not provided, or master if not available
+		var repo *github.Repository
+		repo, _, err = client.Repositories.Get(ctx, owner, repository)
+
+		branch := "master"
+		if err == nil && repo.GetDefaultBranch() != "" {
+			branch = repo.GetDefaultBranch()
+		}
+
+		var ref *github.Reference
+		ref, _, err = client.Git.GetRef(ctx, owner, repository, "heads/"+branch)
+		if err != nil {
+			return errors.Wrap(err, "failed to get github ref")
+		}
+
+		commitSHA = *ref.Object.SHA
+	} else {
+		// Check if sha exists
+		_, _, err = client.Repositories.GetCommit(ctx, owner, repository, commitSHA)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch sha details")
+		}
+
+		if cfg.PluginTagRequireBranch {
+			if err = checkTagCommitBranch(ctx, cfg, client, owner, repository, commitSHA); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.PluginTagRequireChecks {
+		var failures []string
+		failures, err = getCommitCIFailures(ctx, client, owner, repository, commitSHA)
+		if err != nil {
+			return err
+		}
+		if len(failures) > 0 {
+			return errors.Errorf("not tagging %s, its checks are not passing: %s", commitSHA, strings.Join(failures, ", "))
+		}
+	}
+
+	githubTag := &github.Tag{
+		Tag:     github.String(tag),
+		Message: github.String(message),
+		Object: &github.GitObject{
+			SHA:  github.String(commitSHA),
+			Type: github.String("commit"),
+		},
+	}
+	if cfg.PluginTagTaggerName != "" || cfg.PluginTagTaggerEmail != "" {
+		now := time.Now().UTC()
+		githubTag.Tagger = &github.CommitAuthor{
+			Name:  github.String(cfg.PluginTagTaggerName),
+			Email: github.String(cfg.PluginTagTaggerEmail),
+			Date:  &now,
+		}
+	}
+
+	createdTag, _, err := client.Git.CreateTag(ctx, owner, repository, githubTag)
+	if err != nil {
+		return errors.Wrap(err, "failed to create tag")
+	}
+
+	// Point the ref to the tag object, not to the commit, for the tag to be annotated
+	refTag := &github.Reference{
+		Ref: github.String(fmt.Sprintf("tags/%s", tag)),
+		Object: &github.GitObject{
+			SHA:  createdTag.SHA,
+			Type: github.String("tag"),
+		},
+	}
+
+	if _, _, err = client.Git.CreateRef(ctx, owner, repository, refTag); err != nil {
+		return errors.Wrap(err, "failed to create ref")
+	}
+
+	return nil
+}
+
+// pluginTagMessage returns the message of the given plugin tag, PluginTagMessageTemplate
+// executed with the request if set, the tag otherwise.
+func pluginTagMessage(cfg *MatterbuildConfig, request *pluginTagRequest) (string, error) {
+	if cfg.PluginTagMessageTemplate == "" {
+		return request.Tag, nil
+	}
+
+	tmpl, err := template.New("tag").Parse(cfg.PluginTagMessageTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse PluginTagMessageTemplate")
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, request); err != nil {
+		return "", errors.Wrap(err, "failed to execute PluginTagMessageTemplate")
+	}
+
+	return buf.String(), nil
+}
+
+// checkTagCommitBranch checks that the commit is on the default branch of the repository, or on
+// one of its release branches, matching PluginTagReleaseBranches.
+func checkTagCommitBranch(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repository, commitSHA string) error {
+	repo, _, err := client.Repositories.Get(ctx, owner, repository)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get github repo %s", repository)
+	}
+	branches := []string{repo.GetDefaultBranch()}
+
+	patterns := cfg.PluginTagReleaseBranches
+	if len(patterns) == 0 {
+		patterns = defaultPluginTagReleaseBranches
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		list, resp, err := client.Repositories.ListBranches(ctx, owner, repository, opts)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the branches of %s", repository)
+		}
+
+		for _, branch := range list {
+			for _, pattern := range patterns {
+				if matched, _ := path.Match(pattern, branch.GetName()); matched && branch.GetName() != repo.GetDefaultBranch() {
+					branches = append(branches, branch.GetName())
+					break
+				}
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	for _, branch := range branches {
+		comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repository, branch, commitSHA)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compare %s with %s", commitSHA, branch)
+		}
+
+		// The commit is on the branch if the branch is at or ahead of it
+		if status := comparison.GetStatus(); status == "identical" || status == "behind" {
+			LogInfo("Commit %s of %s is on branch %s", commitSHA, repository, branch)
+			return nil
+		}
+	}
+
+	return errors.Errorf("not tagging %s, it is not on any of the branches %s", commitSHA, strings.Join(branches, ", "))
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestCreateTag(t *testing.T) {
+	t.Run("create tag using master's tip", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		owner := "owner"
+		repoName := "repoName"
+		tag := "testTag"
+		commitSHA := ""
+
+		testClient := &GithubClient{
+			Git:          gitMock,
+			Repositories: repoMock,
+		}
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(nil, nil, nil)
+
+		repo := &github.Repository{
+			DefaultBranch: github.String("master"),
+		}
+		repoMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName)).Return(repo, nil, nil)
+
+		masterRef := &github.Reference{
+			Object: &github.GitObject{
+				SHA: github.String("master-SHA"),
+			},
+		}
+		gitMock.EXPECT().GetRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq("heads/master")).Return(masterRef, nil, nil)
+
+		githubObj := &github.GitObject{
+			SHA:  masterRef.Object.SHA,
+			Type: github.String("commit"),
+		}
+		githubTag := &github.Tag{
+			Tag:     github.String(tag),
+			Message: github.String(tag),
+			Object:  githubObj,
+		}
+		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(&github.Tag{SHA: github.String("tag-SHA")}, nil, nil)
+
+		refTag := &github.Reference{
+			Ref: github.String(fmt.Sprintf("tags/%s", tag)),
+			Object: &github.GitObject{
+				SHA:  github.String("tag-SHA"),
+				Type: github.String("tag"),
+			},
+		}
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
+
+		err := createTag(ctx, &MatterbuildConfig{}, testClient, owner, commitSHA, &pluginTagRequest{Repository: repoName, Tag: tag})
+		require.NoError(t, err)
+	})
+
+	t.Run("create tag using given commit SHA", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		owner := "owner"
+		repoName := "repoName"
+		tag := "testTag"
+		commitSHA := "sha"
+
+		testClient := &GithubClient{
+			Git:          gitMock,
+			Repositories: repoMock,
+		}
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(nil, nil, nil)
+
+		githubObj := &github.GitObject{
+			SHA:  github.String(commitSHA),
+			Type: github.String("commit"),
+		}
+		githubTag := &github.Tag{
+			Tag:     github.String(tag),
+			Message: github.String(tag),
+			Object:  githubObj,
+		}
+		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(&github.Tag{SHA: github.String("tag-SHA")}, nil, nil)
+		repoMock.EXPECT().GetCommit(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(commitSHA)).Return(nil, nil, nil)
+
+		refTag := &github.Reference{
+			Ref: github.String(fmt.Sprintf("tags/%s", tag)),
+			Object: &github.GitObject{
+				SHA:  github.String("tag-SHA"),
+				Type: github.String("tag"),
+			},
+		}
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
+
+		err := createTag(ctx, &MatterbuildConfig{}, testClient, owner, commitSHA, &pluginTagRequest{Repository: repoName, Tag: tag})
+		require.NoError(t, err)
+	})
+
+	t.Run("create tag that returns other matching tags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		owner := "owner"
+		repoName := "repoName"
+		tag := "testTag"
+		commitSHA := "sha"
+
+		testClient := &GithubClient{
+			Git:          gitMock,
+			Repositories: repoMock,
+		}
+		refs := []*github.Reference{
+			{
+				Ref: github.String("tags/testTag-1"),
+			},
+			{
+				Ref: github.String("tags/testTag-2"),
+			},
+		}
+
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(refs, nil, nil)
+
+		githubObj := &github.GitObject{
+			SHA:  github.String(commitSHA),
+			Type: github.String("commit"),
+		}
+		githubTag := &github.Tag{
+			Tag:     github.String(tag),
+			Message: github.String(tag),
+			Object:  githubObj,
+		}
+		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(githubTag)).Return(&github.Tag{SHA: github.String("tag-SHA")}, nil, nil)
+		repoMock.EXPECT().GetCommit(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(commitSHA)).Return(nil, nil, nil)
+
+		refTag := &github.Reference{
+			Ref: github.String(fmt.Sprintf("tags/%s", tag)),
+			Object: &github.GitObject{
+				SHA:  github.String("tag-SHA"),
+				Type: github.String("tag"),
+			},
+		}
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(refTag)).Return(nil, nil, nil)
+
+		err := createTag(ctx, &MatterbuildConfig{}, testClient, owner, commitSHA, &pluginTagRequest{Repository: repoName, Tag: tag})
+		require.NoError(t, err)
+	})
+
+	t.Run("create tag that returns matching tags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		owner := "owner"
+		repoName := "repoName"
+		tag := "testTag"
+		commitSHA := "sha"
+
+		testClient := &GithubClient{
+			Git:          gitMock,
+			Repositories: repoMock,
+		}
+		refs := []*github.Reference{
+			{
+				Ref: github.String("tags/testTag-1"),
+			},
+			{
+				Ref: github.String("tags/testTag-2"),
+			},
+			{
+				Ref: github.String("tags/testTag"),
+			},
+		}
+
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), gomock.Eq(owner), gomock.Eq(repoName), gomock.Eq(fmt.Sprintf("tags/%s", tag))).Return(refs, nil, nil)
+
+		err := createTag(ctx, &MatterbuildConfig{}, testClient, owner, commitSHA, &pluginTagRequest{Repository: repoName, Tag: tag})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrTagExists))
+	})
+}
+
+func TestCreateTagOptions(t *testing.T) {
+	ctx := context.Background()
+	owner := "owner"
+	repoName := "repoName"
+	tag := "v1.0.0"
+	commitSHA := "sha"
+	request := &pluginTagRequest{Repository: repoName, Tag: tag, Username: "someone", Command: "/matterbuild cutplugin --tag v1.0.0"}
+
+	setup := func(t *testing.T) (*mocks.MockGithubGitService, *mocks.MockGithubRepositoriesService, *mocks.MockGithubChecksService, *GithubClient) {
+		ctrl := gomock.NewController(t)
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		checksMock := mocks.NewMockGithubChecksService(ctrl)
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), owner, repoName, "tags/"+tag).Return(nil, nil, nil)
+		repoMock.EXPECT().GetCommit(gomock.Eq(ctx), owner, repoName, commitSHA).Return(nil, nil, nil)
+
+		return gitMock, repoMock, checksMock, &GithubClient{Git: gitMock, Repositories: repoMock, Checks: checksMock}
+	}
+
+	t.Run("tagger and message template", func(t *testing.T) {
+		gitMock, _, _, client := setup(t)
+		cfg := &MatterbuildConfig{
+			PluginTagTaggerName:      "Matterbuild",
+			PluginTagTaggerEmail:     "matterbuild@example.com",
+			PluginTagMessageTemplate: "{{.Repository}} {{.Tag}}\n\nRequested by @{{.Username}} with `{{.Command}}`",
+		}
+
+		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), owner, repoName, gomock.Any()).DoAndReturn(func(ctx context.Context, owner, repo string, githubTag *github.Tag) (*github.Tag, *github.Response, error) {
+			require.Equal(t, "repoName v1.0.0\n\nRequested by @someone with `/matterbuild cutplugin --tag v1.0.0`", githubTag.GetMessage())
+			require.Equal(t, "Matterbuild", githubTag.GetTagger().GetName())
+			require.Equal(t, "matterbuild@example.com", githubTag.GetTagger().GetEmail())
+			require.False(t, githubTag.GetTagger().GetDate().IsZero())
+			require.Equal(t, commitSHA, githubTag.GetObject().GetSHA())
+			return &github.Tag{SHA: github.String("tag-SHA")}, nil, nil
+		})
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), owner, repoName, gomock.Any()).Return(nil, nil, nil)
+
+		require.NoError(t, createTag(ctx, cfg, client, owner, commitSHA, request))
+	})
+
+	t.Run("invalid message template", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		gitMock.EXPECT().GetRefs(gomock.Eq(ctx), owner, repoName, "tags/"+tag).Return(nil, nil, nil)
+		cfg := &MatterbuildConfig{PluginTagMessageTemplate: "{{.Tag"}
+
+		err := createTag(ctx, cfg, &GithubClient{Git: gitMock}, owner, commitSHA, request)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse PluginTagMessageTemplate")
+	})
+
+	t.Run("required checks failing", func(t *testing.T) {
+		_, repoMock, checksMock, client := setup(t)
+		cfg := &MatterbuildConfig{PluginTagRequireChecks: true}
+
+		repoMock.EXPECT().GetCombinedStatus(gomock.Eq(ctx), owner, repoName, commitSHA, nil).Return(&github.CombinedStatus{
+			Statuses: []github.RepoStatus{{Context: github.String("ci/circleci"), State: github.String("failure")}},
+		}, nil, nil)
+		checksMock.EXPECT().ListCheckRunsForRef(gomock.Eq(ctx), owner, repoName, commitSHA, gomock.Any()).Return(&github.ListCheckRunsResults{}, nil, nil)
+
+		err := createTag(ctx, cfg, client, owner, commitSHA, request)
+		require.EqualError(t, err, "not tagging sha, its checks are not passing: status ci/circleci is failure")
+	})
+
+	t.Run("commit on a release branch", func(t *testing.T) {
+		gitMock, repoMock, _, client := setup(t)
+		cfg := &MatterbuildConfig{PluginTagRequireBranch: true}
+
+		repoMock.EXPECT().Get(gomock.Eq(ctx), owner, repoName).Return(&github.Repository{DefaultBranch: github.String("master")}, nil, nil)
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), owner, repoName, gomock.Any()).Return([]*github.Branch{
+			{Name: github.String("master")},
+			{Name: github.String("feature")},
+			{Name: github.String("release-1.0")},
+		}, &github.Response{}, nil)
+		repoMock.EXPECT().CompareCommits(gomock.Eq(ctx), owner, repoName, "master", commitSHA).Return(&github.CommitsComparison{Status: github.String("diverged")}, nil, nil)
+		repoMock.EXPECT().CompareCommits(gomock.Eq(ctx), owner, repoName, "release-1.0", commitSHA).Return(&github.CommitsComparison{Status: github.String("behind")}, nil, nil)
+		gitMock.EXPECT().CreateTag(gomock.Eq(ctx), owner, repoName, gomock.Any()).Return(&github.Tag{SHA: github.String("tag-SHA")}, nil, nil)
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), owner, repoName, gomock.Any()).Return(nil, nil, nil)
+
+		require.NoError(t, createTag(ctx, cfg, client, owner, commitSHA, request))
+	})
+
+	t.Run("commit not on any branch", func(t *testing.T) {
+		_, repoMock, _, client := setup(t)
+		cfg := &MatterbuildConfig{PluginTagRequireBranch: true, PluginTagReleaseBranches: []string{"v*"}}
+
+		repoMock.EXPECT().Get(gomock.Eq(ctx), owner, repoName).Return(&github.Repository{DefaultBranch: github.String("main")}, nil, nil)
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), owner, repoName, gomock.Any()).Return([]*github.Branch{
+			{Name: github.String("main")},
+			{Name: github.String("release-1.0")},
+			{Name: github.String("v1")},
+		}, &github.Response{}, nil)
+		repoMock.EXPECT().CompareCommits(gomock.Eq(ctx), owner, repoName, "main", commitSHA).Return(&github.CommitsComparison{Status: github.String("ahead")}, nil, nil)
+		repoMock.EXPECT().CompareCommits(gomock.Eq(ctx), owner, repoName, "v1", commitSHA).Return(&github.CommitsComparison{Status: github.String("diverged")}, nil, nil)
+
+		err := createTag(ctx, cfg, client, owner, commitSHA, request)
+		require.EqualError(t, err, "not tagging sha, it is not on any of the branches main, v1")
+	})
+}
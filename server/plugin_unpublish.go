@@ -0,0 +1,228 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// pluginUnpublishPlan lists what unpublishing a plugin release removes.
+type pluginUnpublishPlan struct {
+	Owner         string
+	Repository    string
+	Tag           string
+	Release       *github.RepositoryRelease
+	GithubAssets  []*github.ReleaseAsset // Signatures, checksums and manifest files of the GitHub release
+	S3Files       []string               // Files of the release in the S3 release bucket
+	DeleteRelease bool                   // Whether the GitHub release and its tag are deleted as well
+}
+
+// planPluginUnpublish finds the files published by cutPlugin for the given release, on GitHub
+// and in the S3 release bucket.
+func planPluginUnpublish(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, owner, repositoryName, tag string, deleteRelease bool) (*pluginUnpublishPlan, error) {
+	release, err := getReleaseByTag(ctx, client, owner, repositoryName, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &pluginUnpublishPlan{
+		Owner:         owner,
+		Repository:    repositoryName,
+		Tag:           tag,
+		Release:       release,
+		DeleteRelease: deleteRelease,
+	}
+
+	prefix := fmt.Sprintf("%s-%s", repositoryName, tag)
+	releaseManifestFiles := []string{prefix + "-SHA256SUMS", prefix + "-SHA256SUMS.sig", prefix + "-manifest.json", prefix + "-manifest.json.sig"}
+
+	var pluginAsset *github.ReleaseAsset
+	for i := range release.Assets {
+		asset := &release.Assets[i]
+		switch {
+		case strings.HasSuffix(asset.GetName(), ".sig"), asset.GetName() == prefix+"-SHA256SUMS", asset.GetName() == prefix+"-manifest.json":
+			plan.GithubAssets = append(plan.GithubAssets, asset)
+		case strings.HasSuffix(asset.GetName(), ".tar.gz"):
+			pluginAsset = asset
+		}
+	}
+
+	tmpFolder, err := os.MkdirTemp("", "plugin-unpublish")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpFolder)
+
+	// The release manifest lists the plugin tars of the release, fall back to the platforms of
+	// the plugin tar for releases published before it
+	var pluginNames []string
+	found, err := downloadFromS3(ctx, cfg, prefix+"-manifest.json", tmpFolder)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		data, err := os.ReadFile(filepath.Join(tmpFolder, prefix+"-manifest.json"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read release manifest")
+		}
+
+		var releaseManifest pluginReleaseManifest
+		if err := json.Unmarshal(data, &releaseManifest); err != nil {
+			return nil, errors.Wrap(err, "failed to parse release manifest")
+		}
+		for _, artifact := range releaseManifest.Artifacts {
+			pluginNames = append(pluginNames, artifact.Name)
+		}
+	} else if pluginAsset != nil {
+		pluginFilePath, err := downloadAsset(ctx, client, owner, repositoryName, pluginAsset, tmpFolder)
+		if err != nil {
+			return nil, err
+		}
+
+		platformBinaries, _, err := findPlatformBinaries(pluginFilePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find platform binaries")
+		}
+
+		pluginNames = append(pluginNames, prefix+".tar.gz")
+		for platform := range platformBinaries {
+			pluginNames = append(pluginNames, fmt.Sprintf("%s-%s.tar.gz", prefix, platform))
+		}
+	} else {
+		pluginNames = append(pluginNames, prefix+".tar.gz")
+	}
+	sort.Strings(pluginNames)
+
+	var candidates []string
+	for _, name := range pluginNames {
+		candidates = append(candidates, name, name+".sig")
+	}
+	candidates = append(candidates, releaseManifestFiles...)
+
+	for _, name := range candidates {
+		exists, err := s3ObjectExists(ctx, cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			plan.S3Files = append(plan.S3Files, name)
+		}
+	}
+
+	return plan, nil
+}
+
+// message formats the files of the plan to get posted into a channel.
+func (p *pluginUnpublishPlan) message() string {
+	msg := fmt.Sprintf("#### %s\n", pluginStatusGithub)
+	for _, asset := range p.GithubAssets {
+		msg += fmt.Sprintf("* `%s`\n", asset.GetName())
+	}
+	if p.DeleteRelease {
+		msg += fmt.Sprintf("* the release and the tag %s\n", p.Tag)
+	} else if len(p.GithubAssets) == 0 {
+		msg += "* nothing\n"
+	}
+
+	msg += fmt.Sprintf("\n#### %s\n", pluginStatusS3)
+	for _, name := range p.S3Files {
+		msg += fmt.Sprintf("* `%s`\n", name)
+	}
+	if len(p.S3Files) == 0 {
+		msg += "* nothing\n"
+	}
+
+	return msg
+}
+
+// unpublishPlugin removes the files of the plan, then the release and its tag if asked to. The
+// action is recorded in the operation store, and the operation returned.
+func unpublishPlugin(ctx context.Context, cfg *MatterbuildConfig, client *GithubClient, plan *pluginUnpublishPlan, username string) (*pluginOperation, error) {
+	op, err := newPluginOperation(cfg, plan.Owner, plan.Repository, plan.Tag, "", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create plugin operation")
+	}
+	op.Action = pluginOperationUnpublish
+	op.Username = username
+	if err := op.save(); err != nil {
+		return nil, err
+	}
+
+	removed := func(location, name string) error {
+		LogInfo("Unpublished %s %s of %s %s", location, name, plan.Repository, plan.Tag)
+		op.Removed = append(op.Removed, location+":"+name)
+		return op.save()
+	}
+
+	for _, asset := range plan.GithubAssets {
+		if _, err := client.Repositories.DeleteReleaseAsset(ctx, plan.Owner, plan.Repository, asset.GetID()); err != nil {
+			return op, errors.Wrapf(err, "failed to delete release asset %s", asset.GetName())
+		}
+		if err := removed(pluginStatusGithub, asset.GetName()); err != nil {
+			return op, err
+		}
+	}
+
+	s3Client := s3.New(newS3Session(cfg))
+	for _, name := range plan.S3Files {
+		_, err := s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(cfg.PluginSigningAWSS3PluginBucket),
+			Key:    aws.String("release/" + name),
+		})
+		if err != nil {
+			return op, errors.Wrapf(err, "failed to delete %s from s3", name)
+		}
+		if err := removed(pluginStatusS3, name); err != nil {
+			return op, err
+		}
+	}
+
+	if plan.DeleteRelease {
+		if _, err := client.Repositories.DeleteRelease(ctx, plan.Owner, plan.Repository, plan.Release.GetID()); err != nil {
+			return op, errors.Wrap(err, "failed to delete release")
+		}
+		if err := removed(pluginStatusGithub, "release "+plan.Tag); err != nil {
+			return op, err
+		}
+
+		if _, err := client.Git.DeleteRef(ctx, plan.Owner, plan.Repository, "tags/"+plan.Tag); err != nil {
+			return op, errors.Wrap(err, "failed to delete tag")
+		}
+		if err := removed(pluginStatusGithub, "tag "+plan.Tag); err != nil {
+			return op, err
+		}
+	}
+
+	return op, nil
+}
+
+// s3ObjectExists returns true if the given file is in the s3 release bucket.
+func s3ObjectExists(ctx context.Context, cfg *MatterbuildConfig, name string) (bool, error) {
+	_, err := s3.New(newS3Session(cfg)).HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(cfg.PluginSigningAWSS3PluginBucket),
+		Key:    aws.String("release/" + name),
+	})
+	if err != nil {
+		var requestErr awserr.RequestFailure
+		if errors.As(err, &requestErr) && requestErr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to check %s in s3", name)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestUnpublishPlugin(t *testing.T) {
+	ctx := context.Background()
+	owner := "owner"
+	repoName := "mattermost-plugin-demo"
+	tag := "v0.4.1"
+
+	s3URL, s3Objects := startTestS3Server(t)
+	cfg := &MatterbuildConfig{
+		PluginSigningAWSAccessKey:      "access",
+		PluginSigningAWSSecretKey:      "secret",
+		PluginSigningAWSRegion:         "us-east-1",
+		PluginSigningAWSS3PluginBucket: "bucket",
+		PluginSigningAWSS3Endpoint:     s3URL,
+		PluginOperationsDir:            t.TempDir(),
+	}
+
+	releaseManifest, err := json.Marshal(&pluginReleaseManifest{
+		Repository: repoName,
+		Tag:        tag,
+		Artifacts: []*pluginArtifact{
+			{Name: "mattermost-plugin-demo-v0.4.1.tar.gz"},
+			{Name: "mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz", Platform: "linux-amd64"},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, name := range []string{
+		"mattermost-plugin-demo-v0.4.1.tar.gz",
+		"mattermost-plugin-demo-v0.4.1.tar.gz.sig",
+		"mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz",
+		"mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz.sig",
+		"mattermost-plugin-demo-v0.4.1-SHA256SUMS",
+		"mattermost-plugin-demo-v0.4.1-SHA256SUMS.sig",
+		"mattermost-plugin-demo-v0.4.1-manifest.json.sig",
+		"mattermost-plugin-demo-v0.4.10.tar.gz",
+	} {
+		s3Objects["release/"+name] = []byte(name)
+	}
+	s3Objects["release/mattermost-plugin-demo-v0.4.1-manifest.json"] = releaseManifest
+
+	release := &github.RepositoryRelease{
+		ID: github.Int64(42),
+		Assets: []github.ReleaseAsset{
+			{ID: github.Int64(1), Name: github.String("mattermost-plugin-demo-v0.4.1.tar.gz")},
+			{ID: github.Int64(2), Name: github.String("mattermost-plugin-demo-v0.4.1.tar.gz.sig")},
+			{ID: github.Int64(3), Name: github.String("mattermost-plugin-demo-v0.4.1-SHA256SUMS")},
+			{ID: github.Int64(4), Name: github.String("mattermost-plugin-demo-v0.4.1-SHA256SUMS.sig")},
+			{ID: github.Int64(5), Name: github.String("mattermost-plugin-demo-v0.4.1-manifest.json")},
+			{ID: github.Int64(6), Name: github.String("mattermost-plugin-demo-v0.4.1-manifest.json.sig")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+	gitMock := mocks.NewMockGithubGitService(ctrl)
+	repoMock.EXPECT().GetReleaseByTag(gomock.Any(), owner, repoName, tag).Return(release, nil, nil)
+	client := &GithubClient{Repositories: repoMock, Git: gitMock}
+
+	plan, err := planPluginUnpublish(ctx, cfg, client, owner, repoName, tag, true)
+	require.NoError(t, err)
+	require.Len(t, plan.GithubAssets, 5, "the plugin tar should be kept")
+	require.Equal(t, []string{
+		"mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz",
+		"mattermost-plugin-demo-v0.4.1-linux-amd64.tar.gz.sig",
+		"mattermost-plugin-demo-v0.4.1.tar.gz",
+		"mattermost-plugin-demo-v0.4.1.tar.gz.sig",
+		"mattermost-plugin-demo-v0.4.1-SHA256SUMS",
+		"mattermost-plugin-demo-v0.4.1-SHA256SUMS.sig",
+		"mattermost-plugin-demo-v0.4.1-manifest.json",
+		"mattermost-plugin-demo-v0.4.1-manifest.json.sig",
+	}, plan.S3Files)
+	require.Contains(t, plan.message(), "* the release and the tag v0.4.1\n")
+
+	for _, id := range []int64{2, 3, 4, 5, 6} {
+		repoMock.EXPECT().DeleteReleaseAsset(gomock.Any(), owner, repoName, id).Return(nil, nil)
+	}
+	repoMock.EXPECT().DeleteRelease(gomock.Any(), owner, repoName, int64(42)).Return(nil, nil)
+	gitMock.EXPECT().DeleteRef(gomock.Any(), owner, repoName, "tags/v0.4.1").Return(nil, nil)
+
+	op, err := unpublishPlugin(ctx, cfg, client, plan, "someone")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"release/mattermost-plugin-demo-v0.4.10.tar.gz": []byte("mattermost-plugin-demo-v0.4.10.tar.gz")}, s3Objects)
+
+	// The action is recorded, and cannot be resumed as a plugin cut
+	recorded, err := loadPluginOperation(cfg, op.ID)
+	require.NoError(t, err)
+	require.Equal(t, pluginOperationUnpublish, recorded.Action)
+	require.Equal(t, "someone", recorded.Username)
+	require.Len(t, recorded.Removed, 15)
+	require.Equal(t, "GitHub:mattermost-plugin-demo-v0.4.1.tar.gz.sig", recorded.Removed[0])
+	require.Equal(t, "GitHub:tag v0.4.1", recorded.Removed[14])
+
+	_, err = runPluginOperation(ctx, cfg, client, recorded)
+	require.EqualError(t, err, "operation "+op.ID+" unpublished v0.4.1 of mattermost-plugin-demo, it cannot be resumed")
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// pluginValidationError reports the problems found in a plugin bundle.
+type pluginValidationError struct {
+	assetName string
+	problems  []string
+}
+
+func (e *pluginValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "plugin bundle %s is invalid:\n", e.assetName)
+	for _, problem := range e.problems {
+		fmt.Fprintf(&sb, "- %s\n", problem)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// validatePluginBundle checks the plugin bundle before signing it: the archive has no entry
+// escaping its root, the manifest is valid and matches the tag, and every declared executable
+// and webapp bundle exists, executables being built for the platform they're declared for.
+// Returns a *pluginValidationError listing every problem found.
+func validatePluginBundle(pluginFilePath, tag string) error {
+	validationErr := &pluginValidationError{assetName: path.Base(pluginFilePath)}
+	addProblem := func(format string, args ...interface{}) {
+		validationErr.problems = append(validationErr.problems, fmt.Sprintf(format, args...))
+	}
+
+	var names []string
+	err := walkPluginArchive(pluginFilePath, func(name string, _ *tar.Header, _ io.Reader) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		addProblem("archive: %s", err.Error())
+		return validationErr
+	}
+
+	manifest, err := readPluginManifest(pluginFilePath)
+	if err != nil {
+		addProblem("manifest: %s", err.Error())
+		return validationErr
+	}
+
+	if !model.IsValidPluginId(manifest.Id) {
+		addProblem("manifest: invalid plugin id %q", manifest.Id)
+	}
+
+	if version := strings.TrimPrefix(tag, "v"); manifest.Version != version {
+		addProblem("manifest: version %q does not match tag %s", manifest.Version, tag)
+	}
+
+	if manifest.MinServerVersion != "" {
+		if _, err = semver.Parse(manifest.MinServerVersion); err != nil {
+			addProblem("manifest: min_server_version %q is not valid semver: %s", manifest.MinServerVersion, err.Error())
+		}
+	}
+
+	// Files that must be in the bundle, and the platforms of executables, if declared
+	expectedFiles := make(map[string][]string)
+	if manifest.Server != nil {
+		for platform, executable := range manifest.Server.Executables {
+			expectedFiles[path.Clean(executable)] = append(expectedFiles[path.Clean(executable)], platform)
+		}
+		if manifest.Server.Executable != "" {
+			expectedFiles[path.Clean(manifest.Server.Executable)] = append(expectedFiles[path.Clean(manifest.Server.Executable)], "")
+		}
+	}
+	if manifest.Webapp != nil {
+		if manifest.Webapp.BundlePath == "" {
+			addProblem("manifest: webapp declared without bundle_path")
+		} else {
+			expectedFiles[path.Clean(manifest.Webapp.BundlePath)] = nil
+		}
+	}
+
+	root := pluginArchiveRoot(names)
+	found := make(map[string]bool)
+	err = walkPluginArchive(pluginFilePath, func(name string, header *tar.Header, r io.Reader) error {
+		relName := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		platforms, expected := expectedFiles[relName]
+		if !expected || header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		found[relName] = true
+
+		if len(platforms) == 0 {
+			// Webapp bundle
+			return nil
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		actualPlatforms, err := detectExecutablePlatforms(data)
+		if err != nil {
+			addProblem("executable %s: %s", relName, err.Error())
+			return nil
+		}
+
+		for _, platform := range platforms {
+			if platform != "" && !containsString(actualPlatforms, platform) {
+				addProblem("executable %s: declared for %s but built for %s", relName, platform, strings.Join(actualPlatforms, ", "))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		addProblem("archive: %s", err.Error())
+	}
+
+	var missing []string
+	for name := range expectedFiles {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		addProblem("%s is declared in the manifest but missing from the bundle", name)
+	}
+
+	if len(validationErr.problems) > 0 {
+		return validationErr
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"archive/tar"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestPlugin writes a valid plugin.tar.gz of the given version, with linux, darwin and
+// windows executables and a webapp bundle.
+func writeTestPlugin(t *testing.T, filePath, version string) {
+	t.Helper()
+
+	manifest := fmt.Sprintf(`{
+		"id": "com.mattermost.demo-plugin",
+		"version": %q,
+		"min_server_version": "5.20.0",
+		"server": {"executables": {
+			"linux-amd64": "server/dist/plugin-linux-amd64",
+			"darwin-amd64": "server/dist/plugin-darwin-amd64",
+			"windows-amd64": "server/dist/plugin-windows-amd64.exe"
+		}},
+		"webapp": {"bundle_path": "webapp/dist/main.js"}
+	}`, version)
+
+	writeTestArchive(t, filePath, []testArchiveEntry{
+		{Name: "com.mattermost.demo-plugin/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "com.mattermost.demo-plugin/plugin.json", Body: manifest},
+		{Name: "com.mattermost.demo-plugin/server/dist/plugin-linux-amd64", Body: string(testELFHeader(elf.EM_X86_64)), Mode: 0755},
+		{Name: "com.mattermost.demo-plugin/server/dist/plugin-darwin-amd64", Body: string(testMachOHeader(macho.CpuAmd64)), Mode: 0755},
+		{Name: "com.mattermost.demo-plugin/server/dist/plugin-windows-amd64.exe", Body: string(testPEHeader(pe.IMAGE_FILE_MACHINE_AMD64)), Mode: 0755},
+		{Name: "com.mattermost.demo-plugin/webapp/dist/main.js", Body: "console.log()"},
+	})
+}
+
+func TestValidatePluginBundle(t *testing.T) {
+	validationProblems := func(t *testing.T, err error) []string {
+		t.Helper()
+
+		require.Error(t, err)
+		var validationErr *pluginValidationError
+		require.ErrorAs(t, err, &validationErr)
+		return validationErr.problems
+	}
+
+	t.Run("valid plugin", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestPlugin(t, pluginPath, "0.4.1")
+
+		require.NoError(t, validatePluginBundle(pluginPath, "v0.4.1"))
+	})
+
+	t.Run("version does not match the tag", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestPlugin(t, pluginPath, "0.4.0")
+
+		err := validatePluginBundle(pluginPath, "v0.4.1")
+		require.Equal(t, []string{`manifest: version "0.4.0" does not match tag v0.4.1`}, validationProblems(t, err))
+		require.Equal(t, "plugin bundle plugin.tar.gz is invalid:\n- manifest: version \"0.4.0\" does not match tag v0.4.1", err.Error())
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, pluginPath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{"id": "com.example.plugin", "version": "1.0.0"}`},
+			{Name: "plugin/../../evil"},
+		})
+
+		problems := validationProblems(t, validatePluginBundle(pluginPath, "v1.0.0"))
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0], "escapes the archive root")
+	})
+
+	t.Run("missing manifest", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, pluginPath, []testArchiveEntry{{Name: "plugin/README.md"}})
+
+		require.Equal(t, []string{"manifest: plugin manifest not found"}, validationProblems(t, validatePluginBundle(pluginPath, "v1.0.0")))
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, pluginPath, []testArchiveEntry{
+			{Name: "plugin/plugin.json", Body: `{
+				"id": "x",
+				"version": "1.0.0",
+				"min_server_version": "latest",
+				"server": {"executables": {
+					"linux-amd64": "server/dist/plugin-linux-amd64",
+					"linux-arm64": "server/dist/plugin-linux-arm64",
+					"windows-amd64": "server/dist/plugin-windows-amd64.exe"
+				}},
+				"webapp": {"bundle_path": "webapp/dist/main.js"}
+			}`},
+			{Name: "plugin/server/dist/plugin-linux-amd64", Body: "#!/bin/sh", Mode: 0755},
+			{Name: "plugin/server/dist/plugin-linux-arm64", Body: string(testELFHeader(elf.EM_X86_64)), Mode: 0755},
+		})
+
+		problems := validationProblems(t, validatePluginBundle(pluginPath, "v1.0.0"))
+		require.Len(t, problems, 6)
+		require.Equal(t, `manifest: invalid plugin id "x"`, problems[0])
+		require.Contains(t, problems[1], `manifest: min_server_version "latest" is not valid semver`)
+		require.Equal(t, "executable server/dist/plugin-linux-amd64: unknown executable format", problems[2])
+		require.Equal(t, "executable server/dist/plugin-linux-arm64: declared for linux-arm64 but built for linux-amd64", problems[3])
+		require.Equal(t, "server/dist/plugin-windows-amd64.exe is declared in the manifest but missing from the bundle", problems[4])
+		require.Equal(t, "webapp/dist/main.js is declared in the manifest but missing from the bundle", problems[5])
+	})
+
+	t.Run("singular executable", func(t *testing.T) {
+		pluginPath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+		writeTestArchive(t, pluginPath, []testArchiveEntry{
+			{Name: "plugin.json", Body: `{"id": "com.example.plugin", "version": "1.0.0", "server": {"executable": "server/dist/plugin"}}`},
+			{Name: "server/dist/plugin", Body: string(testMachOHeader(macho.CpuArm64)), Mode: 0755},
+		})
+
+		require.NoError(t, validatePluginBundle(pluginPath, "v1.0.0"))
+	})
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bndr/gojenkins"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	preflightCheckBranch       = "branch"
+	preflightCheckCI           = "ci"
+	preflightCheckBlockers     = "blockers"
+	preflightCheckTranslations = "translations"
+
+	defaultReleaseBlockerLabel = "Release Blocker"
+)
+
+// preflightCheck is a check run before cutting a release. Run returns the problems found,
+// an empty result meaning the check passed.
+type preflightCheck struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion) ([]string, error)
+}
+
+var preflightChecks = map[string]*preflightCheck{
+	preflightCheckBranch: {
+		Name:        preflightCheckBranch,
+		Description: "Release branch exists in each repository",
+		Run:         checkReleaseBranches,
+	},
+	preflightCheckCI: {
+		Name:        preflightCheckCI,
+		Description: "CI is green at the tip of the release branch",
+		Run:         checkReleaseCI,
+	},
+	preflightCheckBlockers: {
+		Name:        preflightCheckBlockers,
+		Description: "No open release blockers in the milestone",
+		Run:         checkReleaseBlockers,
+	},
+	preflightCheckTranslations: {
+		Name:        preflightCheckTranslations,
+		Description: "Translation server is locked to the release branch",
+		Run:         checkTranslationServer,
+	},
+}
+
+// preflightNotApplicable is returned by checks which don't apply to the release, with
+// the reason reported instead of problems.
+type preflightNotApplicable struct {
+	Reason string
+}
+
+func (e *preflightNotApplicable) Error() string {
+	return e.Reason
+}
+
+type preflightResult struct {
+	Check    *preflightCheck
+	Problems []string
+	Notes    []string
+	Skipped  bool
+}
+
+// preflightReport is the outcome of the pre-flight checks of a release.
+type preflightReport []*preflightResult
+
+// Blocking reports whether a check that was not skipped failed.
+func (r preflightReport) Blocking() bool {
+	for _, result := range r {
+		if len(result.Problems) > 0 && !result.Skipped {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String formats the report as a Markdown table.
+func (r preflightReport) String() string {
+	msg := "| Check | Status | Details |\n|:--|:--|:--|\n"
+	for _, result := range r {
+		status := ":white_check_mark: Passed"
+		details := result.Notes
+		if len(result.Problems) > 0 {
+			status = ":x: Failed"
+			if result.Skipped {
+				status = ":warning: Failed (skipped)"
+			}
+			details = result.Problems
+		}
+
+		msg += fmt.Sprintf("| %s | %s | %s |\n", result.Check.Description, status, strings.Join(details, "<br>"))
+	}
+
+	return msg
+}
+
+// runPreflightChecks runs the given checks for the release. Failures of the checks listed in
+// skip are reported but don't block the release.
+func runPreflightChecks(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion, names, skip []string) (preflightReport, error) {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		if _, ok := preflightChecks[name]; !ok {
+			return nil, errors.Errorf("unknown check %q", name)
+		}
+		skipped[name] = true
+	}
+
+	var report preflightReport
+	for _, name := range names {
+		check, ok := preflightChecks[name]
+		if !ok {
+			return nil, errors.Errorf("unknown check %q", name)
+		}
+
+		LogInfo("Running pre-flight check %s for %s", name, version.String())
+		var notes []string
+		problems, err := check.Run(ctx, client, cfg, version)
+		var notApplicable *preflightNotApplicable
+		if errors.As(err, &notApplicable) {
+			LogInfo("Pre-flight check %s does not apply to %s: %s", name, version.String(), notApplicable.Reason)
+			notes = append(notes, notApplicable.Reason)
+		} else if err != nil {
+			LogError("pre-flight check %s failed err=%s", name, err.Error())
+			problems = append(problems, err.Error())
+		}
+
+		report = append(report, &preflightResult{
+			Check:    check,
+			Problems: problems,
+			Notes:    notes,
+			Skipped:  skipped[name],
+		})
+	}
+
+	return report, nil
+}
+
+// checkReleaseBranches checks that the release branch exists in every configured repository.
+func checkReleaseBranches(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion) ([]string, error) {
+	if version.IsFirstMinorRelease() {
+		return nil, newBranchCreatedByCut(version)
+	}
+
+	var problems []string
+	for _, repository := range getConfiguredRepositories(cfg.Repositories) {
+		if _, err := getReleaseBranch(ctx, client, repository, version.Branch()); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	return problems, nil
+}
+
+// checkReleaseCI checks the commit statuses and check runs of the tip of the release
+// branch in every configured repository.
+func checkReleaseCI(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion) ([]string, error) {
+	if version.IsFirstMinorRelease() {
+		return nil, newBranchCreatedByCut(version)
+	}
+
+	var problems []string
+	for _, repository := range getConfiguredRepositories(cfg.Repositories) {
+		fullName := repository.Owner + "/" + repository.Name
+
+		branch, err := getReleaseBranch(ctx, client, repository, version.Branch())
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		sha := branch.GetCommit().GetSHA()
+		failures, err := getCommitCIFailures(ctx, client, repository.Owner, repository.Name, sha)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, failure := range failures {
+			problems = append(problems, fmt.Sprintf("%s: %s at %s", fullName, failure, sha))
+		}
+	}
+
+	return problems, nil
+}
+
+// newBranchCreatedByCut tells that the release branch can't be checked yet, the cut of
+// the first pre-release of a minor release creating it.
+func newBranchCreatedByCut(version *ReleaseVersion) error {
+	return &preflightNotApplicable{Reason: fmt.Sprintf("branch %s will be created by this cut", version.Branch())}
+}
+
+// getCommitCIFailures returns the commit statuses and check runs of a commit which
+// are not successful. A commit without any status nor check run is reported as well.
+func getCommitCIFailures(ctx context.Context, client *GithubClient, owner, repo, sha string) ([]string, error) {
+	var failures []string
+
+	status, _, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the status of %s in %s/%s", sha, owner, repo)
+	}
+
+	for _, repoStatus := range status.Statuses {
+		if repoStatus.GetState() != "success" {
+			failures = append(failures, fmt.Sprintf("status %s is %s", repoStatus.GetContext(), repoStatus.GetState()))
+		}
+	}
+
+	checkRuns := 0
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		result, resp, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the check runs of %s in %s/%s", sha, owner, repo)
+		}
+
+		for _, checkRun := range result.CheckRuns {
+			checkRuns++
+			if checkRun.GetStatus() != "completed" {
+				failures = append(failures, fmt.Sprintf("check %s is %s", checkRun.GetName(), checkRun.GetStatus()))
+				continue
+			}
+
+			switch checkRun.GetConclusion() {
+			case "success", "neutral", "skipped":
+			default:
+				failures = append(failures, fmt.Sprintf("check %s is %s", checkRun.GetName(), checkRun.GetConclusion()))
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if len(status.Statuses) == 0 && checkRuns == 0 {
+		failures = append(failures, "no CI results")
+	}
+
+	return failures, nil
+}
+
+// checkReleaseBlockers checks that no pull request labeled as release blocker is still open
+// in the milestone of the release.
+func checkReleaseBlockers(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion) ([]string, error) {
+	label := cfg.ReleaseBlockerLabel
+	if label == "" {
+		label = defaultReleaseBlockerLabel
+	}
+
+	var problems []string
+	for _, repository := range getConfiguredRepositories(cfg.Repositories) {
+		query := fmt.Sprintf(`repo:%s/%s is:pr is:open label:"%s" milestone:"v%s"`, repository.Owner, repository.Name, label, version.Release())
+		result, _, err := client.Search.Issues(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to search release blockers of %s/%s", repository.Owner, repository.Name)
+		}
+
+		for _, issue := range result.Issues {
+			problems = append(problems, fmt.Sprintf("%s/%s: [#%d %s](%s) is open", repository.Owner, repository.Name, issue.GetNumber(), issue.GetTitle(), issue.GetHTMLURL()))
+		}
+	}
+
+	return problems, nil
+}
+
+// checkTranslationServer checks that the translation server is locked to the release branch
+// for the server and the webapp.
+func checkTranslationServer(ctx context.Context, client *GithubClient, cfg *MatterbuildConfig, version *ReleaseVersion) ([]string, error) {
+	branches, err := getTranslationServerBranches(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, name := range []string{"PLT_BRANCH", "WEB_BRANCH"} {
+		if branches[name] != version.Branch() {
+			problems = append(problems, fmt.Sprintf("%s is locked to %q instead of %s", name, branches[name], version.Branch()))
+		}
+	}
+
+	return problems, nil
+}
+
+// getTranslationServerBranches runs the job reporting the branches of the translation server
+// and parses its artifact.
+func getTranslationServerBranches(cfg *MatterbuildConfig) (map[string]string, error) {
+	result, appErr := RunJobWaitForResult(cfg.CheckTranslationServerJob, map[string]string{})
+	if appErr != nil {
+		return nil, appErr
+	}
+	if result != gojenkins.STATUS_SUCCESS {
+		return nil, errors.Errorf("translation job finished with status %s", result)
+	}
+
+	artifacts, appErr := GetJenkinsArtifacts(cfg.CheckTranslationServerJob)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	data, err := artifacts[0].GetData()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the translation job artifact")
+	}
+
+	return parseTranslationServerBranches(string(data)), nil
+}
+
+// parseTranslationServerBranches parses the KEY="value" lines reported by the translation job.
+func parseTranslationServerBranches(data string) map[string]string {
+	branches := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		split := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+		branches[strings.TrimSpace(split[0])] = strings.Trim(strings.TrimSpace(split[1]), `"`)
+	}
+
+	return branches
+}
+
+// getReleaseBranch returns the release branch of the repository, or an error describing
+// why it can't be found.
+func getReleaseBranch(ctx context.Context, client *GithubClient, repository *Repository, branch string) (*github.Branch, error) {
+	githubBranch, _, err := client.Repositories.GetBranch(ctx, repository.Owner, repository.Name, branch)
+	if err != nil {
+		var gerr *github.ErrorResponse
+		if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.Errorf("%s/%s: branch %s does not exist", repository.Owner, repository.Name, branch)
+		}
+		return nil, errors.Wrapf(err, "failed to get branch %s of %s/%s", branch, repository.Owner, repository.Name)
+	}
+
+	return githubBranch, nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestRunPreflightChecks(t *testing.T) {
+	cfg := &MatterbuildConfig{
+		Repositories: []*Repository{
+			{Owner: "mattermost", Name: "mattermost-server"},
+			{Owner: "mattermost", Name: "mattermost-webapp"},
+		},
+	}
+
+	version, err := ParseReleaseVersion("5.37.0-rc2")
+	require.NoError(t, err)
+
+	branch := func(sha string) *github.Branch {
+		return &github.Branch{
+			Name:   github.String("release-5.37"),
+			Commit: &github.RepositoryCommit{SHA: github.String(sha)},
+		}
+	}
+
+	type testMocks struct {
+		repo   *mocks.MockGithubRepositoriesService
+		checks *mocks.MockGithubChecksService
+		search *mocks.MockGithubSearchService
+	}
+
+	setup := func(t *testing.T) (*GithubClient, *testMocks) {
+		ctrl := gomock.NewController(t)
+		m := &testMocks{
+			repo:   mocks.NewMockGithubRepositoriesService(ctrl),
+			checks: mocks.NewMockGithubChecksService(ctrl),
+			search: mocks.NewMockGithubSearchService(ctrl),
+		}
+		return &GithubClient{Repositories: m.repo, Checks: m.checks, Search: m.search}, m
+	}
+
+	t.Run("all checks pass", func(t *testing.T) {
+		ctx := context.Background()
+		client, m := setup(t)
+
+		for _, name := range []string{"mattermost-server", "mattermost-webapp"} {
+			m.repo.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", name, "release-5.37").Return(branch(name+"-sha"), nil, nil).Times(2)
+			m.repo.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", name, name+"-sha", nil).Return(&github.CombinedStatus{
+				State:    github.String("success"),
+				Statuses: []github.RepoStatus{{Context: github.String("ci/build"), State: github.String("success")}},
+			}, nil, nil)
+			m.checks.EXPECT().ListCheckRunsForRef(gomock.Eq(ctx), "mattermost", name, name+"-sha", gomock.Any()).Return(&github.ListCheckRunsResults{
+				CheckRuns: []*github.CheckRun{
+					{Name: github.String("lint"), Status: github.String("completed"), Conclusion: github.String("success")},
+					{Name: github.String("e2e"), Status: github.String("completed"), Conclusion: github.String("skipped")},
+				},
+			}, &github.Response{}, nil)
+			m.search.EXPECT().Issues(gomock.Eq(ctx), `repo:mattermost/`+name+` is:pr is:open label:"Release Blocker" milestone:"v5.37.0"`, gomock.Any()).Return(&github.IssuesSearchResult{}, nil, nil)
+		}
+
+		report, err := runPreflightChecks(ctx, client, cfg, version, []string{"branch", "ci", "blockers"}, nil)
+		require.NoError(t, err)
+		require.Len(t, report, 3)
+		require.False(t, report.Blocking())
+		for _, result := range report {
+			require.Empty(t, result.Problems)
+		}
+	})
+
+	t.Run("failures block the release unless skipped", func(t *testing.T) {
+		ctx := context.Background()
+		client, m := setup(t)
+
+		m.repo.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-server", "release-5.37").Return(branch("sha1"), nil, nil)
+		m.repo.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", "mattermost-server", "sha1", nil).Return(&github.CombinedStatus{}, nil, nil)
+		m.checks.EXPECT().ListCheckRunsForRef(gomock.Eq(ctx), "mattermost", "mattermost-server", "sha1", gomock.Any()).Return(&github.ListCheckRunsResults{
+			CheckRuns: []*github.CheckRun{
+				{Name: github.String("lint"), Status: github.String("completed"), Conclusion: github.String("failure")},
+				{Name: github.String("e2e"), Status: github.String("in_progress")},
+			},
+		}, &github.Response{}, nil)
+		m.repo.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "release-5.37").Return(nil, nil, newGithubNotFoundError())
+
+		m.search.EXPECT().Issues(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(&github.IssuesSearchResult{
+			Issues: []github.Issue{{Number: github.Int(42), Title: github.String("Fix crash"), HTMLURL: github.String("https://github.com/mattermost/mattermost-server/pull/42")}},
+		}, nil, nil)
+		m.search.EXPECT().Issues(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(&github.IssuesSearchResult{}, nil, nil)
+
+		report, err := runPreflightChecks(ctx, client, cfg, version, []string{"ci", "blockers"}, []string{"blockers"})
+		require.NoError(t, err)
+		require.True(t, report.Blocking())
+		require.Equal(t, []string{
+			"mattermost/mattermost-server: check lint is failure at sha1",
+			"mattermost/mattermost-server: check e2e is in_progress at sha1",
+			"mattermost/mattermost-webapp: branch release-5.37 does not exist",
+		}, report[0].Problems)
+		require.False(t, report[0].Skipped)
+		require.Equal(t, []string{
+			"mattermost/mattermost-server: [#42 Fix crash](https://github.com/mattermost/mattermost-server/pull/42) is open",
+		}, report[1].Problems)
+		require.True(t, report[1].Skipped)
+
+		require.Contains(t, report.String(), "| CI is green at the tip of the release branch | :x: Failed |")
+		require.Contains(t, report.String(), "| No open release blockers in the milestone | :warning: Failed (skipped) |")
+	})
+
+	t.Run("branch check ignores CI", func(t *testing.T) {
+		ctx := context.Background()
+		client, m := setup(t)
+
+		m.repo.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-server", "release-5.37").Return(nil, nil, newGithubNotFoundError())
+		m.repo.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "release-5.37").Return(branch("sha2"), nil, nil)
+
+		report, err := runPreflightChecks(ctx, client, cfg, version, []string{"branch"}, nil)
+		require.NoError(t, err)
+		require.True(t, report.Blocking())
+		require.Equal(t, []string{
+			"mattermost/mattermost-server: branch release-5.37 does not exist",
+		}, report[0].Problems)
+	})
+
+	t.Run("branch created by the first pre-release of the minor release", func(t *testing.T) {
+		ctx := context.Background()
+		client, _ := setup(t)
+
+		for _, v := range []string{"5.37.0-alpha1", "5.37.0-beta1", "5.37.0-rc1"} {
+			firstVersion, err := ParseReleaseVersion(v)
+			require.NoError(t, err)
+
+			report, err := runPreflightChecks(ctx, client, cfg, firstVersion, []string{"branch", "ci"}, nil)
+			require.NoError(t, err)
+			require.False(t, report.Blocking(), v)
+			for _, result := range report {
+				require.Empty(t, result.Problems, v)
+				require.Equal(t, []string{"branch release-5.37 will be created by this cut"}, result.Notes, v)
+			}
+			require.Contains(t, report.String(), "| Release branch exists in each repository | :white_check_mark: Passed | branch release-5.37 will be created by this cut |")
+		}
+	})
+
+	t.Run("commit without CI results", func(t *testing.T) {
+		ctx := context.Background()
+		client, m := setup(t)
+
+		m.repo.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", "mattermost-server", "sha1", nil).Return(&github.CombinedStatus{State: github.String("pending")}, nil, nil)
+		m.checks.EXPECT().ListCheckRunsForRef(gomock.Eq(ctx), "mattermost", "mattermost-server", "sha1", gomock.Any()).Return(&github.ListCheckRunsResults{}, &github.Response{}, nil)
+
+		failures, err := getCommitCIFailures(ctx, client, "mattermost", "mattermost-server", "sha1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"no CI results"}, failures)
+	})
+
+	t.Run("unknown checks", func(t *testing.T) {
+		client, _ := setup(t)
+
+		_, err := runPreflightChecks(context.Background(), client, cfg, version, []string{"meow"}, nil)
+		require.EqualError(t, err, `unknown check "meow"`)
+
+		_, err = runPreflightChecks(context.Background(), client, cfg, version, []string{"branch"}, []string{"meow"})
+		require.EqualError(t, err, `unknown check "meow"`)
+	})
+}
+
+func TestParseTranslationServerBranches(t *testing.T) {
+	branches := parseTranslationServerBranches("PLT_BRANCH=\"release-5.37\"\nWEB_BRANCH=\"release-5.37\"\nRN_BRANCH=\"master\"\n\n")
+	require.Equal(t, map[string]string{
+		"PLT_BRANCH": "release-5.37",
+		"WEB_BRANCH": "release-5.37",
+		"RN_BRANCH":  "master",
+	}, branches)
+}
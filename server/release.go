@@ -0,0 +1,397 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+var releaseBranchRxp = regexp.MustCompile(`^release-([0-9]+)\.([0-9]+)$`)
+var prereleaseRxp = regexp.MustCompile(`^(rc|beta|alpha)([0-9]+)$`)
+
+// PrereleaseKind is the kind of a pre-release build, e.g. rc for 5.37.0-rc1.
+type PrereleaseKind string
+
+const (
+	PrereleaseNone  PrereleaseKind = ""
+	PrereleaseRC    PrereleaseKind = "rc"
+	PrereleaseBeta  PrereleaseKind = "beta"
+	PrereleaseAlpha PrereleaseKind = "alpha"
+)
+
+// ReleaseVersion is a Mattermost release version, either final (5.37.0) or a
+// pre-release (5.37.0-rc1, 5.37.0-beta1, 5.37.0-alpha1).
+type ReleaseVersion struct {
+	version semver.Version
+
+	Kind   PrereleaseKind
+	Number uint64
+
+	// BranchExists is set once the release branch is known to exist in every configured
+	// repository, e.g. created by an earlier pre-release of the same minor release.
+	BranchExists bool
+}
+
+// ParseReleaseVersion parses and validates a release version given to the cut command.
+func ParseReleaseVersion(versionString string) (*ReleaseVersion, error) {
+	version, err := semver.Parse(versionString)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid release version %q", versionString)
+	}
+
+	if len(version.Build) > 0 {
+		return nil, errors.Errorf("release version %q should not contain build metadata", versionString)
+	}
+
+	releaseVersion := &ReleaseVersion{version: version, Kind: PrereleaseNone}
+	if len(version.Pre) == 0 {
+		return releaseVersion, nil
+	}
+
+	matches := prereleaseRxp.FindStringSubmatch(version.Pre[0].String())
+	if len(version.Pre) != 1 || matches == nil {
+		return nil, errors.Errorf("release version %q should have a pre-release in the form rcN, betaN or alphaN", versionString)
+	}
+
+	number, err := strconv.ParseUint(matches[2], 10, 64)
+	if err != nil || number == 0 {
+		return nil, errors.Errorf("release version %q should have a pre-release number greater than zero", versionString)
+	}
+
+	releaseVersion.Kind = PrereleaseKind(matches[1])
+	releaseVersion.Number = number
+
+	return releaseVersion, nil
+}
+
+// String returns the full version, e.g. 5.37.0-rc1.
+func (v *ReleaseVersion) String() string {
+	return v.version.String()
+}
+
+// Release returns the version without its pre-release part, e.g. 5.37.0.
+func (v *ReleaseVersion) Release() string {
+	return fmt.Sprintf("%d.%d.%d", v.version.Major, v.version.Minor, v.version.Patch)
+}
+
+// Prerelease returns the pre-release suffix including the leading dash, e.g. -rc1,
+// or an empty string for final releases.
+func (v *ReleaseVersion) Prerelease() string {
+	if v.Kind == PrereleaseNone {
+		return ""
+	}
+
+	return fmt.Sprintf("-%s%d", v.Kind, v.Number)
+}
+
+// Branch returns the release branch the version is built from, e.g. release-5.37.
+func (v *ReleaseVersion) Branch() string {
+	return v.line().Branch()
+}
+
+// IsFirstMinorRelease reports whether this is the first pre-release of a new minor
+// release, in which case the release branch needs to be created. A minor release can
+// start with alpha1, beta1 or rc1, whichever is cut first creates the branch, and
+// BranchExists tells the later ones apart.
+func (v *ReleaseVersion) IsFirstMinorRelease() bool {
+	return v.version.Patch == 0 && v.Kind != PrereleaseNone && v.Number == 1 && !v.BranchExists
+}
+
+func (v *ReleaseVersion) line() releaseLine {
+	return releaseLine{Major: v.version.Major, Minor: v.version.Minor}
+}
+
+// releaseLine identifies a major.minor release line, e.g. release-5.37.
+type releaseLine struct {
+	Major uint64
+	Minor uint64
+}
+
+func (l releaseLine) String() string {
+	return fmt.Sprintf("%d.%d", l.Major, l.Minor)
+}
+
+// Branch returns the name of the release branch of the release line.
+func (l releaseLine) Branch() string {
+	return "release-" + l.String()
+}
+
+// Less reports whether l is an older release line than other.
+func (l releaseLine) Less(other releaseLine) bool {
+	if l.Major != other.Major {
+		return l.Major < other.Major
+	}
+
+	return l.Minor < other.Minor
+}
+
+// parseReleaseBranch returns the release line of a release-X.Y branch name.
+func parseReleaseBranch(branch string) (releaseLine, bool) {
+	matches := releaseBranchRxp.FindStringSubmatch(branch)
+	if matches == nil {
+		return releaseLine{}, false
+	}
+
+	major, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return releaseLine{}, false
+	}
+
+	minor, err := strconv.ParseUint(matches[2], 10, 64)
+	if err != nil {
+		return releaseLine{}, false
+	}
+
+	return releaseLine{Major: major, Minor: minor}, true
+}
+
+// parseReleaseLine parses a X.Y release line as given to the branch command.
+func parseReleaseLine(line string) (releaseLine, bool) {
+	return parseReleaseBranch("release-" + line)
+}
+
+// getConfiguredRepositories returns the repositories with both an owner and a name set.
+func getConfiguredRepositories(repositories []*Repository) []*Repository {
+	var result []*Repository
+	for _, repository := range repositories {
+		if repository == nil || repository.Owner == "" || repository.Name == "" {
+			continue
+		}
+		result = append(result, repository)
+	}
+
+	return result
+}
+
+// getLatestReleaseLine lists the release branches of the given repositories and
+// returns the most recent release line found across all of them.
+func getLatestReleaseLine(ctx context.Context, client *GithubClient, repositories []*Repository) (releaseLine, bool, error) {
+	var latest releaseLine
+	found := false
+
+	for _, repository := range getConfiguredRepositories(repositories) {
+		opts := &github.ListOptions{PerPage: 100}
+		for {
+			branches, resp, err := client.Repositories.ListBranches(ctx, repository.Owner, repository.Name, opts)
+			if err != nil {
+				return releaseLine{}, false, errors.Wrapf(err, "failed to list branches of %s/%s", repository.Owner, repository.Name)
+			}
+
+			for _, branch := range branches {
+				line, ok := parseReleaseBranch(branch.GetName())
+				if !ok {
+					continue
+				}
+
+				if !found || latest.Less(line) {
+					latest = line
+					found = true
+				}
+			}
+
+			if resp == nil || resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	return latest, found, nil
+}
+
+// checkBackport verifies that a release which is not flagged as a backport is on the
+// latest release line of the configured repositories.
+// Returns an error describing the newer release line if the release looks like a backport.
+func checkBackport(ctx context.Context, client *GithubClient, repositories []*Repository, line releaseLine) error {
+	latest, found, err := getLatestReleaseLine(ctx, client, repositories)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		LogInfo("no release branches found in the configured repositories, skipping backport check")
+		return nil
+	}
+
+	if line.Less(latest) {
+		return errors.Errorf("Are you sure this isn't a backport release? I see a newer release line on GitHub. (%s)", latest.Branch())
+	}
+
+	return nil
+}
+
+// releaseRepositoriesError lists the problems found by verifyReleaseRepositories.
+type releaseRepositoriesError struct {
+	Problems []string
+}
+
+func (e *releaseRepositoriesError) Error() string {
+	return "release repositories are not ready:\n" + strings.Join(e.Problems, "\n")
+}
+
+// verifyReleaseRepositories checks that the release branch exists in every configured
+// repository and that the commit at its tip has a successful combined status on GitHub.
+// All the problems found are reported in the returned releaseRepositoriesError.
+func verifyReleaseRepositories(ctx context.Context, client *GithubClient, repositories []*Repository, branch string) error {
+	var problems []string
+	for _, repository := range getConfiguredRepositories(repositories) {
+		fullName := repository.Owner + "/" + repository.Name
+
+		githubBranch, _, err := client.Repositories.GetBranch(ctx, repository.Owner, repository.Name, branch)
+		if err != nil {
+			var gerr *github.ErrorResponse
+			if errors.As(err, &gerr) && gerr.Response.StatusCode == http.StatusNotFound {
+				problems = append(problems, fmt.Sprintf("%s: branch %s does not exist", fullName, branch))
+				continue
+			}
+			return errors.Wrapf(err, "failed to get branch %s of %s", branch, fullName)
+		}
+
+		sha := githubBranch.GetCommit().GetSHA()
+		status, _, err := client.Repositories.GetCombinedStatus(ctx, repository.Owner, repository.Name, sha, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the status of %s in %s", sha, fullName)
+		}
+
+		if status.GetState() != "success" {
+			problems = append(problems, fmt.Sprintf("%s: commit %s of %s is not green (%s)", fullName, sha, branch, status.GetState()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &releaseRepositoriesError{Problems: problems}
+	}
+
+	return nil
+}
+
+// releaseBranchExists reports whether the given branch exists in every configured
+// repository. A branch found in only some of them, e.g. left over by a failed cut, is
+// reported as an error: the release job would otherwise skip creating it in the others.
+func releaseBranchExists(ctx context.Context, client *GithubClient, repositories []*Repository, branch string) (bool, error) {
+	var found, missing []string
+	for _, repository := range getConfiguredRepositories(repositories) {
+		fullName := repository.Owner + "/" + repository.Name
+
+		_, _, err := client.Repositories.GetBranch(ctx, repository.Owner, repository.Name, branch)
+		if err == nil {
+			found = append(found, fullName)
+			continue
+		}
+
+		var gerr *github.ErrorResponse
+		if !errors.As(err, &gerr) || gerr.Response.StatusCode != http.StatusNotFound {
+			return false, errors.Wrapf(err, "failed to get branch %s of %s", branch, fullName)
+		}
+		missing = append(missing, fullName)
+	}
+
+	if len(found) > 0 && len(missing) > 0 {
+		return false, errors.Errorf("branch %s exists in %s but not in %s, create or delete it so that all repositories match",
+			branch, strings.Join(found, ", "), strings.Join(missing, ", "))
+	}
+
+	return len(found) > 0, nil
+}
+
+// createReleaseBranches creates the release branch of the given release line from the
+// tip of the default branch in every configured repository. Branches are created
+// atomically: if one of them fails, the ones already created are deleted again.
+// Returns the repositories where the branch was created.
+func createReleaseBranches(ctx context.Context, client *GithubClient, repositories []*Repository, line releaseLine) ([]string, error) {
+	branch := line.Branch()
+	repositories = getConfiguredRepositories(repositories)
+	if len(repositories) == 0 {
+		return nil, errors.New("no repositories configured")
+	}
+
+	// Resolve every starting point before creating anything, so that most failures
+	// don't require a rollback.
+	shas := make([]string, len(repositories))
+	for i, repository := range repositories {
+		fullName := repository.Owner + "/" + repository.Name
+
+		_, _, err := client.Git.GetRef(ctx, repository.Owner, repository.Name, "heads/"+branch)
+		if err == nil {
+			return nil, errors.Errorf("branch %s already exists in %s", branch, fullName)
+		}
+		var gerr *github.ErrorResponse
+		if !errors.As(err, &gerr) || gerr.Response.StatusCode != http.StatusNotFound {
+			return nil, errors.Wrapf(err, "failed to check branch %s of %s", branch, fullName)
+		}
+
+		repo, _, err := client.Repositories.Get(ctx, repository.Owner, repository.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get repository %s", fullName)
+		}
+
+		defaultBranch := repo.GetDefaultBranch()
+		if defaultBranch == "" {
+			defaultBranch = "master"
+		}
+
+		ref, _, err := client.Git.GetRef(ctx, repository.Owner, repository.Name, "heads/"+defaultBranch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get branch %s of %s", defaultBranch, fullName)
+		}
+		shas[i] = ref.GetObject().GetSHA()
+	}
+
+	var created []*Repository
+	for i, repository := range repositories {
+		ref := &github.Reference{
+			Ref: github.String("heads/" + branch),
+			Object: &github.GitObject{
+				SHA: github.String(shas[i]),
+			},
+		}
+
+		if _, _, err := client.Git.CreateRef(ctx, repository.Owner, repository.Name, ref); err != nil {
+			err = errors.Wrapf(err, "failed to create branch %s in %s/%s", branch, repository.Owner, repository.Name)
+			if rollbackErr := deleteReleaseBranches(ctx, client, created, branch); rollbackErr != nil {
+				return nil, errors.Wrapf(err, "rollback failed: %s", rollbackErr.Error())
+			}
+			return nil, err
+		}
+
+		LogInfo("created branch %s in %s/%s at %s", branch, repository.Owner, repository.Name, shas[i])
+		created = append(created, repository)
+	}
+
+	var result []string
+	for _, repository := range created {
+		result = append(result, repository.Owner+"/"+repository.Name)
+	}
+
+	return result, nil
+}
+
+// deleteReleaseBranches deletes the given branch from the repositories, trying all of them
+// even if some fail.
+func deleteReleaseBranches(ctx context.Context, client *GithubClient, repositories []*Repository, branch string) error {
+	var failed []string
+	for _, repository := range repositories {
+		if _, err := client.Git.DeleteRef(ctx, repository.Owner, repository.Name, "heads/"+branch); err != nil {
+			LogError("failed to delete branch %s of %s/%s err=%s", branch, repository.Owner, repository.Name, err.Error())
+			failed = append(failed, repository.Owner+"/"+repository.Name)
+			continue
+		}
+		LogInfo("deleted branch %s of %s/%s", branch, repository.Owner, repository.Name)
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed to delete branch %s in %s", branch, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
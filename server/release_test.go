@@ -0,0 +1,322 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/matterbuild/server/mocks"
+)
+
+func TestParseReleaseBranch(t *testing.T) {
+	line, ok := parseReleaseBranch("release-5.37")
+	require.True(t, ok)
+	require.Equal(t, releaseLine{Major: 5, Minor: 37}, line)
+	require.Equal(t, "release-5.37", line.Branch())
+
+	for _, branch := range []string{"master", "release-5", "release-5.37.1", "release-5.x", "feature-release-5.37"} {
+		_, ok = parseReleaseBranch(branch)
+		require.False(t, ok, branch)
+	}
+}
+
+func TestCheckBackport(t *testing.T) {
+	repositories := []*Repository{
+		{Owner: "mattermost", Name: "mattermost-server"},
+		{Owner: "mattermost", Name: "mattermost-webapp"},
+		{Owner: "", Name: ""},
+	}
+
+	branches := func(names ...string) []*github.Branch {
+		var result []*github.Branch
+		for _, name := range names {
+			result = append(result, &github.Branch{Name: github.String(name)})
+		}
+		return result
+	}
+
+	setup := func(t *testing.T) (*GithubClient, *mocks.MockGithubRepositoriesService) {
+		ctrl := gomock.NewController(t)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		return &GithubClient{Repositories: repoMock}, repoMock
+	}
+
+	t.Run("release on the latest release line", func(t *testing.T) {
+		ctx := context.Background()
+		client, repoMock := setup(t)
+
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-server", gomock.Any()).Return(branches("master", "release-5.36", "release-5.37"), &github.Response{}, nil)
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-webapp", gomock.Any()).Return(branches("master", "release-5.37"), &github.Response{}, nil)
+
+		require.NoError(t, checkBackport(ctx, client, repositories, releaseLine{Major: 5, Minor: 37}))
+	})
+
+	t.Run("release of a new release line", func(t *testing.T) {
+		ctx := context.Background()
+		client, repoMock := setup(t)
+
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-server", gomock.Any()).Return(branches("release-5.37"), &github.Response{}, nil)
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-webapp", gomock.Any()).Return(branches("release-5.37"), &github.Response{}, nil)
+
+		require.NoError(t, checkBackport(ctx, client, repositories, releaseLine{Major: 5, Minor: 38}))
+	})
+
+	t.Run("release on an older release line", func(t *testing.T) {
+		ctx := context.Background()
+		client, repoMock := setup(t)
+
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-server", gomock.Any()).Return(branches("release-5.9", "release-5.10"), &github.Response{}, nil)
+		repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-webapp", gomock.Any()).Return(branches("release-6.0"), &github.Response{}, nil)
+
+		err := checkBackport(ctx, client, repositories, releaseLine{Major: 5, Minor: 10})
+		require.EqualError(t, err, "Are you sure this isn't a backport release? I see a newer release line on GitHub. (release-6.0)")
+	})
+
+	t.Run("follows pagination", func(t *testing.T) {
+		ctx := context.Background()
+		client, repoMock := setup(t)
+
+		gomock.InOrder(
+			repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-server", &github.ListOptions{PerPage: 100}).Return(branches("release-5.37"), &github.Response{NextPage: 2}, nil),
+			repoMock.EXPECT().ListBranches(gomock.Eq(ctx), "mattermost", "mattermost-server", &github.ListOptions{PerPage: 100, Page: 2}).Return(branches("release-5.38"), &github.Response{}, nil),
+		)
+
+		err := checkBackport(ctx, client, repositories[:1], releaseLine{Major: 5, Minor: 37})
+		require.Error(t, err)
+	})
+
+	t.Run("no configured repositories", func(t *testing.T) {
+		client, _ := setup(t)
+		require.NoError(t, checkBackport(context.Background(), client, nil, releaseLine{Major: 5, Minor: 37}))
+	})
+}
+
+func TestParseReleaseVersion(t *testing.T) {
+	testCases := []struct {
+		version             string
+		release             string
+		prerelease          string
+		kind                PrereleaseKind
+		branch              string
+		isFirstMinorRelease bool
+	}{
+		{"5.37.0", "5.37.0", "", PrereleaseNone, "release-5.37", false},
+		{"5.37.0-rc1", "5.37.0", "-rc1", PrereleaseRC, "release-5.37", true},
+		{"5.37.0-rc2", "5.37.0", "-rc2", PrereleaseRC, "release-5.37", false},
+		{"5.37.1-rc1", "5.37.1", "-rc1", PrereleaseRC, "release-5.37", false},
+		{"5.37.10", "5.37.10", "", PrereleaseNone, "release-5.37", false},
+		{"5.37.12-rc3", "5.37.12", "-rc3", PrereleaseRC, "release-5.37", false},
+		{"10.1.0-beta1", "10.1.0", "-beta1", PrereleaseBeta, "release-10.1", true},
+		{"10.1.0-beta2", "10.1.0", "-beta2", PrereleaseBeta, "release-10.1", false},
+		{"10.1.0-alpha1", "10.1.0", "-alpha1", PrereleaseAlpha, "release-10.1", true},
+		{"10.1.1-alpha1", "10.1.1", "-alpha1", PrereleaseAlpha, "release-10.1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			version, err := ParseReleaseVersion(tc.version)
+			require.NoError(t, err)
+			require.Equal(t, tc.version, version.String())
+			require.Equal(t, tc.release, version.Release())
+			require.Equal(t, tc.prerelease, version.Prerelease())
+			require.Equal(t, tc.kind, version.Kind)
+			require.Equal(t, tc.branch, version.Branch())
+			require.Equal(t, tc.isFirstMinorRelease, version.IsFirstMinorRelease())
+		})
+	}
+
+	t.Run("release branch created by an earlier pre-release", func(t *testing.T) {
+		for _, v := range []string{"10.1.0-alpha1", "10.1.0-beta1", "10.1.0-rc1"} {
+			version, err := ParseReleaseVersion(v)
+			require.NoError(t, err)
+			version.BranchExists = true
+			require.False(t, version.IsFirstMinorRelease(), v)
+		}
+	})
+
+	for _, invalid := range []string{"", "5.37", "5.37.0.1", "v5.37.0", "5a37b0", "5.37.0-rc", "5.37.0-rc0", "5.37.0-rc.1", "5.37.0-gamma1", "5.37.0-rc1-beta1", "5.37.0+build1"} {
+		t.Run("invalid "+invalid, func(t *testing.T) {
+			version, err := ParseReleaseVersion(invalid)
+			require.Error(t, err)
+			require.Nil(t, version)
+		})
+	}
+}
+
+func newGithubNotFoundError() error {
+	return &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
+func TestVerifyReleaseRepositories(t *testing.T) {
+	repositories := []*Repository{
+		{Owner: "mattermost", Name: "mattermost-server"},
+		{Owner: "mattermost", Name: "mattermost-webapp"},
+	}
+
+	branch := func(sha string) *github.Branch {
+		return &github.Branch{
+			Name:   github.String("release-5.37"),
+			Commit: &github.RepositoryCommit{SHA: github.String(sha)},
+		}
+	}
+
+	status := func(state string) *github.CombinedStatus {
+		return &github.CombinedStatus{State: github.String(state)}
+	}
+
+	t.Run("all repositories are ready", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-server", "release-5.37").Return(branch("sha1"), nil, nil)
+		repoMock.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", "mattermost-server", "sha1", nil).Return(status("success"), nil, nil)
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "release-5.37").Return(branch("sha2"), nil, nil)
+		repoMock.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "sha2", nil).Return(status("success"), nil, nil)
+
+		require.NoError(t, verifyReleaseRepositories(ctx, client, repositories, "release-5.37"))
+	})
+
+	t.Run("reports missing branches and failing commits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-server", "release-5.37").Return(nil, nil, newGithubNotFoundError())
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "release-5.37").Return(branch("sha2"), nil, nil)
+		repoMock.EXPECT().GetCombinedStatus(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "sha2", nil).Return(status("failure"), nil, nil)
+
+		err := verifyReleaseRepositories(ctx, client, repositories, "release-5.37")
+		require.EqualError(t, err, "release repositories are not ready:\n"+
+			"mattermost/mattermost-server: branch release-5.37 does not exist\n"+
+			"mattermost/mattermost-webapp: commit sha2 of release-5.37 is not green (failure)")
+	})
+}
+
+func TestReleaseBranchExists(t *testing.T) {
+	repositories := []*Repository{
+		{Owner: "mattermost", Name: "mattermost-server"},
+		{Owner: "mattermost", Name: "mattermost-webapp"},
+	}
+
+	t.Run("created by an alpha", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", gomock.Any(), "release-10.1").Return(&github.Branch{Name: github.String("release-10.1")}, nil, nil).Times(2)
+
+		exists, err := releaseBranchExists(ctx, client, repositories, "release-10.1")
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("only in some repositories", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-server", "release-10.1").Return(nil, nil, newGithubNotFoundError())
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", "mattermost-webapp", "release-10.1").Return(&github.Branch{Name: github.String("release-10.1")}, nil, nil)
+
+		_, err := releaseBranchExists(ctx, client, repositories, "release-10.1")
+		require.EqualError(t, err, "branch release-10.1 exists in mattermost/mattermost-webapp but not in mattermost/mattermost-server, create or delete it so that all repositories match")
+	})
+
+	t.Run("not created yet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+		client := &GithubClient{Repositories: repoMock}
+
+		repoMock.EXPECT().GetBranch(gomock.Eq(ctx), "mattermost", gomock.Any(), "release-10.1").Return(nil, nil, newGithubNotFoundError()).Times(2)
+
+		exists, err := releaseBranchExists(ctx, client, repositories, "release-10.1")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
+
+func TestCreateReleaseBranches(t *testing.T) {
+	repositories := []*Repository{
+		{Owner: "mattermost", Name: "mattermost-server"},
+		{Owner: "mattermost", Name: "mattermost-webapp"},
+	}
+	line := releaseLine{Major: 5, Minor: 37}
+
+	ref := func(sha string) *github.Reference {
+		return &github.Reference{Object: &github.GitObject{SHA: github.String(sha)}}
+	}
+
+	newBranchRef := func(sha string) *github.Reference {
+		return &github.Reference{
+			Ref:    github.String("heads/release-5.37"),
+			Object: &github.GitObject{SHA: github.String(sha)},
+		}
+	}
+
+	setup := func(t *testing.T, ctx context.Context) (*GithubClient, *mocks.MockGithubGitService) {
+		ctrl := gomock.NewController(t)
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		repoMock := mocks.NewMockGithubRepositoriesService(ctrl)
+
+		for i, repository := range repositories {
+			gitMock.EXPECT().GetRef(gomock.Eq(ctx), repository.Owner, repository.Name, "heads/release-5.37").Return(nil, nil, newGithubNotFoundError())
+			repoMock.EXPECT().Get(gomock.Eq(ctx), repository.Owner, repository.Name).Return(&github.Repository{DefaultBranch: github.String("master")}, nil, nil)
+			gitMock.EXPECT().GetRef(gomock.Eq(ctx), repository.Owner, repository.Name, "heads/master").Return(ref(fmt.Sprintf("sha%d", i)), nil, nil)
+		}
+
+		return &GithubClient{Git: gitMock, Repositories: repoMock}, gitMock
+	}
+
+	t.Run("creates the branch in all repositories", func(t *testing.T) {
+		ctx := context.Background()
+		client, gitMock := setup(t, ctx)
+
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), "mattermost", "mattermost-server", newBranchRef("sha0")).Return(nil, nil, nil)
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), "mattermost", "mattermost-webapp", newBranchRef("sha1")).Return(nil, nil, nil)
+
+		created, err := createReleaseBranches(ctx, client, repositories, line)
+		require.NoError(t, err)
+		require.Equal(t, []string{"mattermost/mattermost-server", "mattermost/mattermost-webapp"}, created)
+	})
+
+	t.Run("rolls back created branches on failure", func(t *testing.T) {
+		ctx := context.Background()
+		client, gitMock := setup(t, ctx)
+
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), "mattermost", "mattermost-server", newBranchRef("sha0")).Return(nil, nil, nil)
+		gitMock.EXPECT().CreateRef(gomock.Eq(ctx), "mattermost", "mattermost-webapp", newBranchRef("sha1")).Return(nil, nil, errors.New("boom"))
+		gitMock.EXPECT().DeleteRef(gomock.Eq(ctx), "mattermost", "mattermost-server", "heads/release-5.37").Return(nil, nil)
+
+		created, err := createReleaseBranches(ctx, client, repositories, line)
+		require.EqualError(t, err, "failed to create branch release-5.37 in mattermost/mattermost-webapp: boom")
+		require.Nil(t, created)
+	})
+
+	t.Run("fails without changes if the branch already exists", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+		gitMock := mocks.NewMockGithubGitService(ctrl)
+		client := &GithubClient{Git: gitMock}
+
+		gitMock.EXPECT().GetRef(gomock.Eq(ctx), "mattermost", "mattermost-server", "heads/release-5.37").Return(ref("sha0"), nil, nil)
+
+		created, err := createReleaseBranches(ctx, client, repositories, line)
+		require.EqualError(t, err, "branch release-5.37 already exists in mattermost/mattermost-server")
+		require.Nil(t, created)
+	})
+}
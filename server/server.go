@@ -11,11 +11,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 
-	"github.com/blang/semver"
 	"github.com/bndr/gojenkins"
 	"github.com/gorilla/schema"
 	"github.com/julienschmidt/httprouter"
@@ -147,6 +144,7 @@ func Start() {
 	router.GET("/", indexHandler)
 	router.GET("/healthz", healthHandler)
 	router.POST("/slash_command", slashCommandHandler)
+	router.POST("/github_webhook", githubWebhookHandler)
 
 	LogInfo("Running Matterbuild on port " + Cfg.ListenAddress)
 	if err := http.ListenAndServe(Cfg.ListenAddress, router); err != nil {
@@ -192,7 +190,7 @@ func checkSlashPermissions(command *MMSlashCommand, rootCmd *cobra.Command) *App
 	}
 
 	subCommand, _, _ := rootCmd.Find(strings.Fields(strings.TrimSpace(command.Text)))
-	if subCommand.Name() == "cut" || subCommand.Name() == "cutplugin" {
+	if subCommand.Name() == "cut" || subCommand.Name() == "cutplugin" || subCommand.Name() == "cutplugins" || subCommand.Name() == "unpublish" || subCommand.Name() == "branch" || subCommand.Name() == "changelog" {
 		hasPermissions = false
 		for _, allowedUser := range Cfg.ReleaseUsers {
 			if allowedUser == command.UserID {
@@ -218,14 +216,16 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 	var cutCmd = &cobra.Command{
 		Use:   "cut [release]",
 		Short: "Cut a release of Mattermost",
-		Long:  "Cut a release of Mattermost. Version should be specified in the format 0.0.0-rc0 or 0.0.0 for final releases.",
+		Long:  "Cut a release of Mattermost. Version should be specified in the format 0.0.0-rc1, 0.0.0-beta1, 0.0.0-alpha1 or 0.0.0 for final releases. The first of the alpha1, beta1 or rc1 of a minor release creates the release branch, the cut is refused while the branch only exists in some of the repositories.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			backport, _ := cmd.Flags().GetBool("backport")
 			dryrun, _ := cmd.Flags().GetBool("dryrun")
 			legacy, _ := cmd.Flags().GetBool("legacy")
 			server, _ := cmd.Flags().GetString("server")
 			webapp, _ := cmd.Flags().GetString("webapp")
-			return cutReleaseCommandF(args, w, command, backport, dryrun, legacy, server, webapp)
+			checkRepos, _ := cmd.Flags().GetBool("check-repos")
+			skipChecks, _ := cmd.Flags().GetStringSlice("skip-checks")
+			return cutReleaseCommandF(args, w, command, backport, dryrun, legacy, server, webapp, checkRepos, skipChecks)
 		},
 	}
 	cutCmd.Flags().Bool("backport", false, "Set this flag for releases that are not on the current major release branch.")
@@ -233,6 +233,17 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 	cutCmd.Flags().Bool("legacy", false, "Set this flag to build release older then release number 5.7.x.")
 	cutCmd.Flags().String("server", "", "Set this flag to define the Docker image used to build the server. Optional the job will use the hardcoded one if not defined")
 	cutCmd.Flags().String("webapp", "", "Set this flag to define the Docker image used to build the webapp. Optional the job will use the hardcoded one if not defined")
+	cutCmd.Flags().Bool("check-repos", false, "Set this flag to verify that the release branch exists and is green in every configured repository before cutting.")
+	cutCmd.Flags().StringSlice("skip-checks", nil, "Set this flag to the comma separated names of the pre-flight checks whose failures should not block the release.")
+
+	var branchCmd = &cobra.Command{
+		Use:   "branch [release]",
+		Short: "Create the release branch in all the configured repositories",
+		Long:  "Create the release-X.Y branch from the tip of the default branch in all the configured repositories. Release should be specified in the format 0.0. If the branch can't be created in one repository, the branches already created are deleted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return branchCommandF(args, w, command)
+		},
+	}
 
 	var configDumpCmd = &cobra.Command{
 		Use:   "seeconf",
@@ -242,8 +253,21 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 		},
 	}
 
+	var changelogCmd = &cobra.Command{
+		Use:   "changelog [from-tag] [to-ref]",
+		Short: "Generate a changelog draft from the pull requests merged between two refs",
+		Long:  "Generate a changelog draft from the pull requests merged between two refs of the configured repositories, grouped by label. The draft is posted to the channel, or used as the body of the draft GitHub release of to-ref with --release.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, _ := cmd.Flags().GetString("repo")
+			release, _ := cmd.Flags().GetBool("release")
+			return changelogCommandF(args, w, command, repo, release)
+		},
+	}
+	changelogCmd.Flags().String("repo", "", "Set this flag to only generate the changelog of the given repository (name or owner/name). Defaults to all the configured repositories.")
+	changelogCmd.Flags().Bool("release", false, "Set this flag to create or update the draft GitHub release of to-ref instead of posting the changelog.")
+
 	var cutPluginCmd = &cobra.Command{
-		Use:   "cutplugin [--tag] [--repo] [--commitSHA] [--force] [--pre-release]",
+		Use:   "cutplugin [--tag] [--repo] [--commitSHA] [--force] [--pre-release] [--marketplace=official|community [--beta] [--enterprise]] [--resume]",
 		Short: "Cut a release of any plugin under Mattermost Organization",
 		Long:  "Cut a release of any plugin under Mattermost Organization.",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -253,7 +277,18 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 			assetName, _ := cmd.Flags().GetString("asset-name")
 			force, _ := cmd.Flags().GetBool("force")
 			preRelease, _ := cmd.Flags().GetBool("pre-release")
-			return cutPluginCommandF(w, command, tag, repo, commitSHA, assetName, force, preRelease)
+			marketplace, _ := cmd.Flags().GetString("marketplace")
+			beta, _ := cmd.Flags().GetBool("beta")
+			enterprise, _ := cmd.Flags().GetBool("enterprise")
+			marketplaceOpts, err := parseMarketplaceOptions(marketplace, beta, enterprise)
+			if err != nil {
+				WriteErrorResponse(w, NewError(err.Error(), nil))
+				return nil
+			}
+			if resume, _ := cmd.Flags().GetString("resume"); resume != "" {
+				return resumeCutPluginCommandF(w, command, resume, marketplaceOpts)
+			}
+			return cutPluginCommandF(w, command, tag, repo, commitSHA, assetName, force, preRelease, marketplaceOpts)
 		},
 	}
 	cutPluginCmd.Flags().String("tag", "", "Set this flag for the tag you want to release.")
@@ -262,6 +297,55 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 	cutPluginCmd.Flags().String("asset-name", "", "Set this flag for the file name of the asset to sign. Defaults to the asset with `.tar.gz` extension.")
 	cutPluginCmd.Flags().Bool("force", false, "Set this flag to regenerate assets for a given repository.")
 	cutPluginCmd.Flags().Bool("pre-release", false, "Set this flag to label this version as pre-release.")
+	cutPluginCmd.Flags().String("marketplace", "", "Set this flag to official or community to open a Marketplace pull request once the plugin is published.")
+	cutPluginCmd.Flags().Bool("beta", false, "Set this flag to add the release to the Marketplace as beta.")
+	cutPluginCmd.Flags().Bool("enterprise", false, "Set this flag to mark the release as requiring an enterprise license in the Marketplace.")
+	cutPluginCmd.Flags().String("resume", "", "Set this flag to the operation id of a failed release to continue it from its first incomplete stage. The other flags but the Marketplace ones are ignored.")
+
+	var cutPluginsCmd = &cobra.Command{
+		Use:   "cutplugins [repo@tag|bundle]...",
+		Short: "Cut the releases of several plugins",
+		Long:  "Create the tags and sign the releases of the given plugins, or of the plugins of a bundle configured in PluginBundles, a few at a time. Posts a summary once all are done.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			preRelease, _ := cmd.Flags().GetBool("pre-release")
+			return cutPluginsCommandF(args, w, command, force, preRelease)
+		},
+	}
+	cutPluginsCmd.Flags().Bool("force", false, "Set this flag to regenerate the assets of the plugins already tagged.")
+	cutPluginsCmd.Flags().Bool("pre-release", false, "Set this flag to label these versions as pre-release.")
+
+	var unpublishCmd = &cobra.Command{
+		Use:   "unpublish",
+		Short: "Unpublish a plugin release",
+		Long:  "Remove the signatures, checksums and manifest files of a plugin release from GitHub, and its plugin tars and signatures from the S3 release bucket. Lists what would be removed unless --confirm is given.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, _ := cmd.Flags().GetString("repo")
+			tag, _ := cmd.Flags().GetString("tag")
+			deleteRelease, _ := cmd.Flags().GetBool("delete-release")
+			confirm, _ := cmd.Flags().GetBool("confirm")
+			return unpublishCommandF(w, command, repo, tag, deleteRelease, confirm)
+		},
+	}
+	unpublishCmd.Flags().String("repo", "", "Set this flag for the plugin repository.")
+	unpublishCmd.Flags().String("tag", "", "Set this flag for the tag of the release to unpublish.")
+	unpublishCmd.Flags().Bool("delete-release", false, "Set this flag to also delete the GitHub release and its tag.")
+	unpublishCmd.Flags().Bool("confirm", false, "Set this flag to unpublish the release, once checked what gets removed.")
+
+	var pluginCmd = &cobra.Command{
+		Use:   "plugin",
+		Short: "Inspect plugin releases",
+	}
+
+	var pluginStatusCmd = &cobra.Command{
+		Use:   "status [repo] [tag]",
+		Short: "Check that a plugin release is fully published",
+		Long:  "Check that the plugin tars, signatures, checksums and manifest files of a plugin release are published on GitHub and S3, and that the signatures verify. Tag defaults to the latest release.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pluginStatusCommandF(args, w, command)
+		},
+	}
+	pluginCmd.AddCommand(pluginStatusCmd)
 
 	var setCIBranchCmd = &cobra.Command{
 		Use:   "setci",
@@ -322,6 +406,8 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 
 	rootCmd.AddCommand(
 		cutCmd,
+		branchCmd,
+		changelogCmd,
 		configDumpCmd,
 		setCIBranchCmd,
 		runJobCmd,
@@ -329,6 +415,9 @@ func initCommands(w http.ResponseWriter, command *MMSlashCommand) *cobra.Command
 		lockTranslationServerCmd,
 		checkBranchTranslationCmd,
 		cutPluginCmd,
+		cutPluginsCmd,
+		unpublishCmd,
+		pluginCmd,
 		pipelineTriggerCmd,
 	)
 
@@ -362,91 +451,208 @@ func slashCommandHandler(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	}
 }
 
-var finalVersionRxp = regexp.MustCompile("^[0-9]+.[0-9]+.[0-9]+$")
-var rcRxp = regexp.MustCompile("^[0-9]+.[0-9]+.[0-9]+-rc[0-9]+$")
-
 func cutReleaseCommandF(args []string, w http.ResponseWriter, slashCommand *MMSlashCommand, backport bool,
-	dryrun bool, legacy bool, server string, webapp string) error {
+	dryrun bool, legacy bool, server string, webapp string, checkRepos bool, skipChecks []string) error {
 	if len(args) < 1 {
 		return NewError("You need to specify a release version.", nil)
 	}
 
-	versionString := args[0]
-
-	// Check the version string given and split into release part (0.0.0) and rc part (rc0)
-	// Also determine if this is RC1 of a .0 build in which case we need to branch
-	var releasePart string
-	var rcPart string
-	var isFirstMinorRelease bool
+	version, err := ParseReleaseVersion(args[0])
+	if err != nil {
+		WriteErrorResponse(w, NewError("Bad version argument.", err))
+		return nil
+	}
 
-	switch {
-	case rcRxp.MatchString(versionString):
-		split := strings.Split(versionString, "-")
-		if len(split) != 2 {
-			WriteErrorResponse(w, NewError("Bad version argument. Can't split on -. Typo? If not the regex might be broken. If so be more careful!!", nil))
-			return nil
-		}
-		releasePart = split[0]
-		rcPart = split[1]
-		isFirstMinorRelease = (rcPart == "rc1" && strings.HasSuffix(releasePart, ".0"))
-	case finalVersionRxp.MatchString(versionString):
-		releasePart = versionString
-		rcPart = ""
-		isFirstMinorRelease = false
-	default:
-		WriteErrorResponse(w, NewError("Bad version argument. Typo? If not the regex might be broken. If so be more careful!!", nil))
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError("Unable to create the GitHub client.", err))
 		return nil
 	}
 
 	// Check that the release dev hasn't forgotten to get --backport
 	if !backport {
-		splitRelease := strings.Split(releasePart, ".")
-		if len(splitRelease) != 3 {
-			WriteErrorResponse(w, NewError("Bad version argument.", nil))
+		if err := checkBackport(ctx, client, Cfg.Repositories, version.line()); err != nil {
+			WriteErrorResponse(w, NewError(err.Error(), nil))
 			return nil
 		}
-		intVer, err := strconv.Atoi(splitRelease[1])
+	}
+
+	// An earlier alpha or beta of the minor release may have created its branch already,
+	// in which case the release job must not create it again
+	if version.IsFirstMinorRelease() {
+		version.BranchExists, err = releaseBranchExists(ctx, client, Cfg.Repositories, version.Branch())
 		if err != nil {
-			WriteErrorResponse(w, NewError("Bad version argument.", nil))
+			WriteErrorResponse(w, NewError(err.Error(), nil))
+			return nil
+		}
+	}
+
+	if checkRepos {
+		if err := verifyReleaseRepositories(ctx, client, Cfg.Repositories, version.Branch()); err != nil {
+			WriteErrorResponse(w, NewError(err.Error(), nil))
 			return nil
 		}
-		splitRelease[1] = strconv.Itoa(intVer + 1)
-		splitRelease[2] = "0"
+	}
 
-		oneReleaseUp := strings.Join(splitRelease, ".")
+	var report preflightReport
+	if len(Cfg.CutPreflightChecks) > 0 {
+		report, err = runPreflightChecks(ctx, client, Cfg, version, Cfg.CutPreflightChecks, skipChecks)
+		if err != nil {
+			WriteErrorResponse(w, NewError(err.Error(), nil))
+			return nil
+		}
 
-		s3URL := "http://releases.mattermost.com/" + oneReleaseUp + "-rc1/mattermost-" + oneReleaseUp + "-rc1-linux-amd64.tar.gz"
-		resp, err := http.Get(s3URL)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			resp.Body.Close()
-			WriteErrorResponse(w, NewError("Are you sure this isn't a backport release? I see a future release on s3. ("+oneReleaseUp+")"+http.StatusText(resp.StatusCode), nil))
+		if report.Blocking() {
+			msg := fmt.Sprintf("Release **%v** is blocked by failed pre-flight checks. Fix them or use `--skip-checks` to ignore them.\n\n%s", version.String(), report.String())
+			WriteEnrichedResponse(w, "Cut Release", msg, "#ee2116", model.CommandResponseTypeInChannel)
 			return nil
 		}
 	}
 
-	err := CutRelease(releasePart, rcPart, isFirstMinorRelease, backport, dryrun, legacy, server, webapp)
-	if err != nil {
-		WriteErrorResponse(w, err)
+	if appErr := CutRelease(version, backport, dryrun, legacy, server, webapp); appErr != nil {
+		WriteErrorResponse(w, appErr)
 	} else {
 		msg := fmt.Sprintf("Release **%v** is on the way.", args[0])
+		if len(report) > 0 {
+			msg += "\n\n" + report.String()
+		}
 		WriteEnrichedResponse(w, "Cut Release", msg, "#0060aa", model.CommandResponseTypeInChannel)
 	}
 
 	return nil
 }
 
-func cutPluginCommandF(w http.ResponseWriter, slashCommand *MMSlashCommand, tag, repo, commitSHA, assetName string, force bool, preRelease bool) error {
-	if tag == "" {
-		WriteErrorResponse(w, NewError("Tag should not be empty", nil))
+func branchCommandF(args []string, w http.ResponseWriter, slashCommand *MMSlashCommand) error {
+	if len(args) < 1 {
+		return NewError("You need to specify a release, e.g. 5.37.", nil)
+	}
+
+	line, ok := parseReleaseLine(args[0])
+	if !ok {
+		WriteErrorResponse(w, NewError("Bad release argument. Release should be specified in the format 0.0.", nil))
 		return nil
 	}
-	if tag[0] != 'v' {
-		WriteErrorResponse(w, NewError("Tag must start with leading 'v'", nil))
+
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError("Unable to create the GitHub client.", err))
 		return nil
 	}
 
-	if _, err := semver.Parse(tag[1:]); err != nil {
-		WriteErrorResponse(w, NewError(fmt.Sprintf("Tag must adhere to semver after leading 'v': %s", err.Error()), nil))
+	repositories, err := createReleaseBranches(ctx, client, Cfg.Repositories, line)
+	if err != nil {
+		LogError("failed to create release branches err=%s", err.Error())
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	msg := fmt.Sprintf("@%s created branch **%s** in:\n", slashCommand.Username, line.Branch())
+	for _, repository := range repositories {
+		msg += fmt.Sprintf("* %s\n", repository)
+	}
+	WriteEnrichedResponse(w, "Release Branch", msg, "#0060aa", model.CommandResponseTypeInChannel)
+	return nil
+}
+
+func changelogCommandF(args []string, w http.ResponseWriter, slashCommand *MMSlashCommand, repo string, release bool) error {
+	if len(args) < 2 {
+		return NewError("You need to specify the tag to start from and the ref to end at.", nil)
+	}
+	from, to := args[0], args[1]
+
+	repositories, err := getChangelogRepositories(repo)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError("Unable to create the GitHub client.", err))
+		return nil
+	}
+
+	msg := fmt.Sprintf("Generating the changelog from %s to %s. Will report back when done.", from, to)
+	WriteEnrichedResponse(w, "Changelog", msg, "#0060aa", model.CommandResponseTypeEphemeral)
+
+	go func() {
+		msg := ""
+		color := "#0060aa"
+		for _, repository := range repositories {
+			fullName := repository.Owner + "/" + repository.Name
+
+			pullRequests, truncated, err := getMergedPullRequests(ctx, client, repository.Owner, repository.Name, from, to)
+			if err != nil {
+				LogError("failed to generate changelog err=%s", err.Error())
+				msg += fmt.Sprintf("## %s\nError while generating the changelog: %s\n\n", fullName, err.Error())
+				color = "#fc081c"
+				continue
+			}
+			changelog := formatChangelog(pullRequests, truncated)
+
+			if !release {
+				msg += fmt.Sprintf("## %s\n%s\n", fullName, changelog)
+				continue
+			}
+
+			draft, err := publishChangelogDraft(ctx, client, repository.Owner, repository.Name, to, changelog)
+			if err != nil {
+				LogError("failed to publish changelog draft err=%s", err.Error())
+				msg += fmt.Sprintf("* %s: Error while updating the draft release: %s\n", fullName, err.Error())
+				color = "#fc081c"
+				continue
+			}
+			msg += fmt.Sprintf("* %s: [Draft release %s](%s) updated with %d pull requests\n", fullName, to, draft.GetHTMLURL(), len(pullRequests))
+		}
+
+		if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Changelog", msg, color, model.CommandResponseTypeInChannel)); err != nil {
+			LogError("failed to post changelog through PostExtraMessages err=%s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// getChangelogRepositories returns the repositories to generate a changelog for: the given
+// one, either as owner/name or as the name of a configured repository, or all the configured ones.
+// Only configured repositories and repositories of GithubOrg are accepted.
+func getChangelogRepositories(repo string) ([]*Repository, error) {
+	configured := getConfiguredRepositories(Cfg.Repositories)
+	if repo == "" {
+		if len(configured) == 0 {
+			return nil, errors.New("no repositories configured, use --repo")
+		}
+		return configured, nil
+	}
+
+	if split := strings.SplitN(repo, "/", 2); len(split) == 2 {
+		for _, repository := range configured {
+			if strings.EqualFold(repository.Owner, split[0]) && strings.EqualFold(repository.Name, split[1]) {
+				return []*Repository{repository}, nil
+			}
+		}
+
+		if Cfg.GithubOrg == "" || !strings.EqualFold(split[0], Cfg.GithubOrg) {
+			return nil, errors.Errorf("repository %s is neither configured nor part of %s", repo, Cfg.GithubOrg)
+		}
+		return []*Repository{{Owner: split[0], Name: split[1]}}, nil
+	}
+
+	for _, repository := range configured {
+		if repository.Name == repo {
+			return []*Repository{repository}, nil
+		}
+	}
+
+	return []*Repository{{Owner: Cfg.GithubOrg, Name: repo}}, nil
+}
+
+func cutPluginCommandF(w http.ResponseWriter, slashCommand *MMSlashCommand, tag, repo, commitSHA, assetName string, force bool, preRelease bool, marketplaceOpts *marketplaceOptions) error {
+	if err := validatePluginTag(tag); err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
 		return nil
 	}
 
@@ -456,57 +662,292 @@ func cutPluginCommandF(w http.ResponseWriter, slashCommand *MMSlashCommand, tag,
 	}
 
 	ctx := context.Background()
-	client := NewGithubClient(ctx, Cfg.GithubAccessToken)
-	if err := checkRepo(ctx, client, Cfg.GithubOrg, repo); err != nil {
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+	if err := checkRepo(ctx, Cfg, client, Cfg.GithubOrg, repo); err != nil {
 		WriteErrorResponse(w, NewError(err.Error(), nil))
 		return nil
 	}
 
+	unlock, ok := lockPluginRelease(Cfg.GithubOrg, repo, tag)
+	if !ok {
+		WriteErrorResponse(w, NewError(fmt.Sprintf("@%s %s of %s is already being released.", slashCommand.Username, tag, repo), nil))
+		return nil
+	}
+
 	releasePrefix := ""
 	if preRelease {
 		releasePrefix = "pre-"
 	}
 	command := slashCommand.Command + " " + slashCommand.Text
 	msg := fmt.Sprintf("@%s triggered a plugin %srelease process using `%s`.\nTag %s created in`%s`. Waiting for the artifacts to sign and publish.\nWill report back when the process completes.\nGrab :coffee: and a :doughnut: ", slashCommand.Username, releasePrefix, command, tag, repo)
-	if err := createTag(ctx, client, Cfg.GithubOrg, repo, tag, commitSHA); errors.Is(err, ErrTagExists) {
+	if err := createTag(ctx, Cfg, client, Cfg.GithubOrg, commitSHA, &pluginTagRequest{Repository: repo, Tag: tag, Username: slashCommand.Username, Command: command}); errors.Is(err, ErrTagExists) {
 		if !force {
+			unlock()
 			WriteErrorResponse(w, NewError(fmt.Sprintf("@%s Tag %s already exists in %s. Not generating any artifacts. Use --force to regenerate artifacts.", slashCommand.Username, tag, repo), nil))
 			return nil
 		}
 		msg = fmt.Sprintf("@%s Tag %s already exists in %s. Waiting for the artifacts to sign and publish.\nWill report back when the process completes.\nGrab :coffee: and a :doughnut: ", slashCommand.Username, tag, repo)
 	} else if err != nil {
+		unlock()
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	WriteEnrichedResponse(w, "Plugin Release Process", msg, "#0060aa", model.CommandResponseTypeInChannel)
+
+	go func() {
+		defer unlock()
+		result, err := cutPlugin(ctx, Cfg, client, Cfg.GithubOrg, repo, tag, assetName, preRelease, marketplaceOpts != nil)
+		reportCutPlugin(ctx, client, slashCommand.ResponseURL, slashCommand.Username, repo, tag, commitSHA, marketplaceOpts, result, err)
+	}()
+	return nil
+}
+
+func resumeCutPluginCommandF(w http.ResponseWriter, slashCommand *MMSlashCommand, operationID string, marketplaceOpts *marketplaceOptions) error {
+	op, err := loadPluginOperation(Cfg, operationID)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	// The repository may have been denied or archived since the cut failed
+	if err := checkRepo(ctx, Cfg, client, op.Owner, op.Repository); err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	unlock, ok := lockPluginRelease(op.Owner, op.Repository, op.Tag)
+	if !ok {
+		WriteErrorResponse(w, NewError(fmt.Sprintf("@%s %s of %s is already being released.", slashCommand.Username, op.Tag, op.Repository), nil))
+		return nil
+	}
+
+	msg := fmt.Sprintf("@%s resumed the plugin release process of %s in `%s` from stage %s.\nWill report back when the process completes.", slashCommand.Username, op.Tag, op.Repository, op.next())
+	WriteEnrichedResponse(w, "Plugin Release Process", msg, "#0060aa", model.CommandResponseTypeInChannel)
+
+	// The Marketplace flags of the resumed run decide whether the entry is described
+	op.Marketplace = marketplaceOpts != nil
+
+	go func() {
+		defer unlock()
+		result, err := runPluginOperation(ctx, Cfg, client, op)
+		reportCutPlugin(ctx, client, slashCommand.ResponseURL, slashCommand.Username, op.Repository, op.Tag, "", marketplaceOpts, result, err)
+	}()
+	return nil
+}
+
+// reportCutPlugin posts the outcome of a plugin cut to responseURL, opening the Marketplace pull
+// request if asked to.
+func reportCutPlugin(ctx context.Context, client *GithubClient, responseURL, username, repo, tag, commitSHA string, marketplaceOpts *marketplaceOptions, result *cutPluginResult, err error) {
+	if err != nil {
+		LogError("failed to cutplugin %s", err.Error())
+		errMsg := fmt.Sprintf("Error while signing plugin\nError: %s", err.Error())
+		var opErr *pluginOperationError
+		if errors.As(err, &opErr) {
+			errMsg += fmt.Sprintf("\nOnce the problem is fixed, run `/matterbuild cutplugin --resume %s` to continue from stage %s.", opErr.ID, opErr.Stage)
+		}
+		errColor := "#fc081c"
+		if err := PostExtraMessages(responseURL, GenerateEnrichedSlashResponse("Plugin Release Process", errMsg, errColor, model.CommandResponseTypeInChannel)); err != nil {
+			LogError("failed to post err through PostExtraMessages err=%s", err.Error())
+		}
+		return
+	}
+
+	// Get release link if possible
+	releaseURL := ""
+	if release, err := getReleaseByTag(ctx, client, Cfg.GithubOrg, repo, tag); err != nil {
+		LogError("failed to get release by tag after err=%s", err.Error())
+	} else {
+		releaseURL = release.GetHTMLURL()
+	}
+
+	marketplacePRURL := ""
+	var marketplaceErr error
+	if marketplaceOpts != nil {
+		marketplacePRURL, marketplaceErr = createMarketplacePullRequest(ctx, Cfg, client, result.Marketplace, tag, marketplaceOpts)
+		if marketplaceErr != nil {
+			LogError("failed to create marketplace pull request err=%s", marketplaceErr.Error())
+		}
+	}
+
+	msg := getSuccessMessage(tag, repo, commitSHA, releaseURL, marketplacePRURL, username)
+	if result.Notice != "" {
+		msg = result.Notice + "\n\n" + msg
+	}
+	if marketplaceErr != nil {
+		msg += fmt.Sprintf("\n\nFailed to open the Marketplace pull request, please open it by hand.\nError: %s", marketplaceErr.Error())
+	}
+	msg += "\n\nSHA-256 checksums:\n```\n" + result.Checksums + "```"
+
+	color := "#0060aa"
+	if err := PostExtraMessages(responseURL, GenerateEnrichedSlashResponse("Plugin Release Process", msg, color, model.CommandResponseTypeInChannel)); err != nil {
+		LogError("failed to post success msg through PostExtraMessages err=%s", err.Error())
+	}
+}
+
+func cutPluginsCommandF(args []string, w http.ResponseWriter, slashCommand *MMSlashCommand, force, preRelease bool) error {
+	items, err := parsePluginBatch(Cfg, args)
+	if err != nil {
 		WriteErrorResponse(w, NewError(err.Error(), nil))
 		return nil
 	}
 
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	releases := make([]string, 0, len(items))
+	for _, item := range items {
+		releases = append(releases, fmt.Sprintf("`%s` %s", item.Repository, item.Tag))
+	}
+	msg := fmt.Sprintf("@%s triggered the release process of %d plugins: %s.\nWill report back when all complete.\nGrab :coffee: and a :doughnut: ", slashCommand.Username, len(items), strings.Join(releases, ", "))
 	WriteEnrichedResponse(w, "Plugin Release Process", msg, "#0060aa", model.CommandResponseTypeInChannel)
 
 	go func() {
-		if err := cutPlugin(ctx, Cfg, client, Cfg.GithubOrg, repo, tag, assetName, preRelease); err != nil {
-			LogError("failed to cutplugin %s", err.Error())
-			errMsg := fmt.Sprintf("Error while signing plugin\nError: %s", err.Error())
-			errColor := "#fc081c"
-			if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Release Process", errMsg, errColor, model.CommandResponseTypeInChannel)); err != nil {
-				LogError("failed to post err through PostExtraMessages err=%s", err.Error())
+		cutPluginBatch(ctx, Cfg, client, Cfg.GithubOrg, items, slashCommand.Username, slashCommand.Command+" "+slashCommand.Text, force, preRelease)
+
+		color := "#0060aa"
+		for _, item := range items {
+			if item.Status == pluginBatchFailed {
+				color = "#fc081c"
 			}
-			return
 		}
 
-		// Get release link if possible
-		releaseURL := ""
-		if release, err := getReleaseByTag(ctx, client, Cfg.GithubOrg, repo, tag); err != nil {
-			LogError("failed to get release by tag after err=%s", err.Error())
+		if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Release Process", pluginBatchSummary(items), color, model.CommandResponseTypeInChannel)); err != nil {
+			LogError("failed to post plugin batch summary through PostExtraMessages err=%s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func unpublishCommandF(w http.ResponseWriter, slashCommand *MMSlashCommand, repo, tag string, deleteRelease, confirm bool) error {
+	if repo == "" {
+		WriteErrorResponse(w, NewError("Plugin Repository should not be empty", nil))
+		return nil
+	}
+	if err := validatePluginTag(tag); err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+	if err := checkRepo(ctx, Cfg, client, Cfg.GithubOrg, repo); err != nil {
+		WriteErrorResponse(w, NewError(err.Error(), nil))
+		return nil
+	}
+
+	if !confirm {
+		WriteEnrichedResponse(w, "Plugin Unpublish", fmt.Sprintf("Checking what unpublishing %s of `%s` removes. Will report back when done.", tag, repo), "#0060aa", model.CommandResponseTypeEphemeral)
+
+		go func() {
+			msg, color := "", "#0060aa"
+			plan, err := planPluginUnpublish(ctx, Cfg, client, Cfg.GithubOrg, repo, tag, deleteRelease)
+			if err != nil {
+				LogError("failed to plan plugin unpublish err=%s", err.Error())
+				msg, color = fmt.Sprintf("Error while checking the release %s of %s\nError: %s", tag, repo, err.Error()), "#fc081c"
+			} else {
+				msg = fmt.Sprintf("Unpublishing %s of `%s` removes:\n\n%s\nRun `%s %s --confirm` to unpublish it.", tag, repo, plan.message(), slashCommand.Command, strings.TrimSpace(slashCommand.Text))
+			}
+
+			if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Unpublish", msg, color, model.CommandResponseTypeEphemeral)); err != nil {
+				LogError("failed to post plugin unpublish plan through PostExtraMessages err=%s", err.Error())
+			}
+		}()
+		return nil
+	}
+
+	unlock, ok := lockPluginRelease(Cfg.GithubOrg, repo, tag)
+	if !ok {
+		WriteErrorResponse(w, NewError(fmt.Sprintf("@%s %s of %s is being released, try again once done.", slashCommand.Username, tag, repo), nil))
+		return nil
+	}
+
+	WriteEnrichedResponse(w, "Plugin Unpublish", fmt.Sprintf("@%s is unpublishing %s of `%s`. Will report back when done.", slashCommand.Username, tag, repo), "#0060aa", model.CommandResponseTypeInChannel)
+
+	go func() {
+		defer unlock()
+
+		msg, color := "", "#0060aa"
+		var op *pluginOperation
+		plan, err := planPluginUnpublish(ctx, Cfg, client, Cfg.GithubOrg, repo, tag, deleteRelease)
+		if err == nil {
+			op, err = unpublishPlugin(ctx, Cfg, client, plan, slashCommand.Username)
+		}
+
+		if err != nil {
+			LogError("failed to unpublish plugin err=%s", err.Error())
+			msg, color = fmt.Sprintf("Error while unpublishing %s of %s\nError: %s", tag, repo, err.Error()), "#fc081c"
+			if op != nil {
+				msg += fmt.Sprintf("\nThe files removed so far are recorded in operation %s.", op.ID)
+			}
 		} else {
-			releaseURL = release.GetHTMLURL()
+			msg = fmt.Sprintf("@%s unpublished %s of `%s`, recorded in operation %s. Removed:\n\n%s", slashCommand.Username, tag, repo, op.ID, plan.message())
 		}
 
-		msg := getSuccessMessage(tag, repo, commitSHA, releaseURL, slashCommand.Username)
+		if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Unpublish", msg, color, model.CommandResponseTypeInChannel)); err != nil {
+			LogError("failed to post plugin unpublish through PostExtraMessages err=%s", err.Error())
+		}
+	}()
 
-		color := "#0060aa"
-		if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Release Process", msg, color, model.CommandResponseTypeInChannel)); err != nil {
-			LogError("failed to post success msg through PostExtraMessages err=%s", err.Error())
+	return nil
+}
+
+func pluginStatusCommandF(args []string, w http.ResponseWriter, slashCommand *MMSlashCommand) error {
+	if len(args) < 1 {
+		return NewError("You need to specify the plugin repository.", nil)
+	}
+	repo, tag := args[0], ""
+	if len(args) > 1 {
+		tag = args[1]
+	}
+
+	ctx := context.Background()
+	client, err := NewGithubClient(ctx, Cfg)
+	if err != nil {
+		WriteErrorResponse(w, NewError("Unable to create the GitHub client.", err))
+		return nil
+	}
+
+	WriteEnrichedResponse(w, "Plugin Release Status", fmt.Sprintf("Checking the release of %s. Will report back when done.", repo), "#0060aa", model.CommandResponseTypeEphemeral)
+
+	go func() {
+		msg, color := "", "#0060aa"
+		status, err := getPluginReleaseStatus(ctx, Cfg, client, Cfg.GithubOrg, repo, tag)
+		if err != nil {
+			LogError("failed to get plugin release status err=%s", err.Error())
+			msg, color = fmt.Sprintf("Error while checking the release of %s\nError: %s", repo, err.Error()), "#fc081c"
+		} else {
+			msg = status.message()
+			if len(status.problems()) > 0 {
+				color = "#fc081c"
+			}
+		}
+
+		if err := PostExtraMessages(slashCommand.ResponseURL, GenerateEnrichedSlashResponse("Plugin Release Status", msg, color, model.CommandResponseTypeEphemeral)); err != nil {
+			LogError("failed to post plugin release status through PostExtraMessages err=%s", err.Error())
 		}
 	}()
+
 	return nil
 }
 
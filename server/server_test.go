@@ -20,6 +20,10 @@ func TestCheckSlashPermissions(t *testing.T) {
 		commands := []*MMSlashCommand{
 			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "cut 0.0.0-rc0"},
 			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "cutplugin --tag v0.0.0-rc0 --repo testplugin"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "cutplugins testplugin@v0.0.0-rc0"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "unpublish --repo testplugin --tag v0.0.0-rc0"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "branch 0.0"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid1", Text: "changelog v0.0.0 v0.1.0 --release"},
 		}
 
 		rootCmd := initCommands(nil, nil)
@@ -41,6 +45,10 @@ func TestCheckSlashPermissions(t *testing.T) {
 			{Command: "/matterbuild", Token: "token", UserID: "userid2", Text: "cutplugin --tag v0.0.0-rc0 --repo testplugin"},
 			{Command: "/matterbuild", Token: "token", UserID: "userid3", Text: "cutplugin --tag v0.0.0-rc0 --repo testplugin"},
 			{Command: "/matterbuild", Token: "token", UserID: "userid4", Text: "cutplugin --tag v0.0.0-rc0 --repo testplugin"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid2", Text: "cutplugins testplugin@v0.0.0-rc0"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid2", Text: "unpublish --repo testplugin --tag v0.0.0-rc0 --confirm"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid2", Text: "branch 0.0"},
+			{Command: "/matterbuild", Token: "token", UserID: "userid2", Text: "changelog v0.0.0 v0.1.0 --release"},
 		}
 		rootCmd := initCommands(nil, nil)
 		for _, command := range commands {
@@ -48,3 +56,32 @@ func TestCheckSlashPermissions(t *testing.T) {
 		}
 	})
 }
+
+func TestGetChangelogRepositories(t *testing.T) {
+	Cfg = &MatterbuildConfig{
+		GithubOrg: "mattermost",
+		Repositories: []*Repository{
+			{Owner: "mattermost", Name: "mattermost-server"},
+			{Owner: "fork", Name: "mattermost-webapp"},
+		},
+	}
+
+	repositories, err := getChangelogRepositories("")
+	require.NoError(t, err)
+	require.Len(t, repositories, 2)
+
+	repositories, err = getChangelogRepositories("mattermost-webapp")
+	require.NoError(t, err)
+	require.Equal(t, []*Repository{{Owner: "fork", Name: "mattermost-webapp"}}, repositories)
+
+	repositories, err = getChangelogRepositories("fork/mattermost-webapp")
+	require.NoError(t, err)
+	require.Equal(t, []*Repository{{Owner: "fork", Name: "mattermost-webapp"}}, repositories)
+
+	repositories, err = getChangelogRepositories("mattermost/mattermost-plugin-demo")
+	require.NoError(t, err)
+	require.Equal(t, []*Repository{{Owner: "mattermost", Name: "mattermost-plugin-demo"}}, repositories)
+
+	_, err = getChangelogRepositories("someone/mattermost-server")
+	require.EqualError(t, err, "repository someone/mattermost-server is neither configured nor part of mattermost")
+}
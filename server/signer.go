@@ -0,0 +1,57 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	signingBackendSSH   = "ssh"
+	signingBackendGPG   = "gpg"
+	signingBackendLocal = "local"
+)
+
+// Signer signs plugin files. The detached signature of each file is written to <filePath>.sig.
+type Signer interface {
+	Sign(ctx context.Context, filePaths []string) error
+}
+
+// newSigner returns the Signer of the configured plugin signing backend, which defaults to
+// the remote SSH signing server.
+func newSigner(cfg *MatterbuildConfig) (Signer, error) {
+	switch cfg.PluginSigningBackend {
+	case "", signingBackendSSH:
+		return newSSHSigner(cfg), nil
+	case signingBackendGPG:
+		return newGPGSigner(cfg.PluginSigningGPGHomeDir, cfg.PluginSigningGPGKeyID, cfg.PluginSigningGPGPassphrasePath)
+	case signingBackendLocal:
+		return newLocalSigner(cfg.PluginSigningPrivateKeyPath, cfg.PluginSigningPrivateKeyPassphrasePath)
+	default:
+		return nil, errors.Errorf("unknown plugin signing backend %q", cfg.PluginSigningBackend)
+	}
+}
+
+// signPlugins signs plugin tar files with the given signer and verifies the signatures.
+// Signature files are named <filePath>.sig. Returns the ID of the key that signed each file.
+func signPlugins(ctx context.Context, cfg *MatterbuildConfig, signer Signer, filePaths []string) (map[string]string, error) {
+	if err := signer.Sign(ctx, filePaths); err != nil {
+		return nil, err
+	}
+
+	keyring, err := loadPluginPublicKeyring(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load plugin public key")
+	}
+
+	// Verify signatures.
+	keyIDs, err := verifySignatures(keyring, filePaths)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed signature verification")
+	}
+
+	return keyIDs, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// gpgSigner signs plugins with a key of a local GnuPG keyring. It is meant for development,
+// together with PluginSigningPublicKeyPath pointing to the public part of the key.
+type gpgSigner struct {
+	homeDir        string
+	keyID          string
+	passphrasePath string
+}
+
+func newGPGSigner(homeDir, keyID, passphrasePath string) (*gpgSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("missing gpg key id")
+	}
+
+	return &gpgSigner{
+		homeDir:        homeDir,
+		keyID:          keyID,
+		passphrasePath: passphrasePath,
+	}, nil
+}
+
+func (s *gpgSigner) Sign(ctx context.Context, filePaths []string) error {
+	for _, filePath := range filePaths {
+		LogInfo("Signing %s with gpg key %s", filePath, s.keyID)
+
+		args := []string{"--batch", "--yes", "--local-user", s.keyID}
+		if s.homeDir != "" {
+			args = append(args, "--homedir", s.homeDir)
+		}
+		if s.passphrasePath != "" {
+			args = append(args, "--pinentry-mode", "loopback", "--passphrase-file", s.passphrasePath)
+		}
+		args = append(args, "--output", filePath+".sig", "--detach-sign", filePath)
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "gpg", args...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "failed to sign %s: %s", filePath, stderr.String())
+		}
+	}
+
+	LogInfo("Done signing")
+	return nil
+}
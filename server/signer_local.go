@@ -0,0 +1,98 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+)
+
+// localSigner signs plugins in-process with an armored private key file. It needs neither the
+// signing server nor gpg, which makes it suitable for development and tests.
+type localSigner struct {
+	entity *openpgp.Entity
+}
+
+func newLocalSigner(privateKeyPath, passphrasePath string) (*localSigner, error) {
+	if privateKeyPath == "" {
+		return nil, errors.New("missing private key path")
+	}
+
+	f, err := os.Open(privateKeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open private key")
+	}
+	defer f.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read private key")
+	}
+	if len(keyring) != 1 {
+		return nil, errors.Errorf("expected a single key in %s, found %d", privateKeyPath, len(keyring))
+	}
+
+	entity := keyring[0]
+	if entity.PrivateKey == nil {
+		return nil, errors.Errorf("%s does not contain a private key", privateKeyPath)
+	}
+
+	if entity.PrivateKey.Encrypted {
+		if passphrasePath == "" {
+			return nil, errors.New("private key is encrypted but no passphrase was configured")
+		}
+
+		passphrase, err := os.ReadFile(passphrasePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read passphrase")
+		}
+
+		if err := entity.PrivateKey.Decrypt([]byte(strings.TrimSpace(string(passphrase)))); err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt private key")
+		}
+	}
+
+	return &localSigner{entity: entity}, nil
+}
+
+func (s *localSigner) Sign(ctx context.Context, filePaths []string) error {
+	for _, filePath := range filePaths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		LogInfo("Signing %s with local key %s", filePath, s.entity.PrimaryKey.KeyIdString())
+		if err := s.signFile(filePath); err != nil {
+			return errors.Wrapf(err, "failed to sign %s", filePath)
+		}
+	}
+
+	LogInfo("Done signing")
+	return nil
+}
+
+func (s *localSigner) signFile(filePath string) error {
+	in, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(fmt.Sprintf("%s.sig", filePath))
+	if err != nil {
+		return err
+	}
+
+	if err := openpgp.DetachSign(out, s.entity, in, nil); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
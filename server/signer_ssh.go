@@ -0,0 +1,490 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultPluginSigningSSHPort        = 22
+	defaultPluginSigningSSHParallelism = 4
+
+	remoteWorkspaceParentDir = "/tmp"
+	remoteSignerScript       = "/opt/plugin-signer/sign_plugin.sh"
+)
+
+// sshSigner signs plugins on the remote signing server: files are copied over SFTP and
+// signed by the signer script, and the signatures are copied back.
+type sshSigner struct {
+	cfg *MatterbuildConfig
+}
+
+func newSSHSigner(cfg *MatterbuildConfig) *sshSigner {
+	return &sshSigner{cfg: cfg}
+}
+
+func (s *sshSigner) Sign(ctx context.Context, filePaths []string) error {
+	session, err := openSigningSession(s.cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the signing server")
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			LogError("failed to clean up signing session: %s", err.Error())
+		}
+	}()
+
+	if err := session.createWorkspace(remoteWorkspaceParentDir); err != nil {
+		return errors.Wrap(err, "failed to create remote workspace")
+	}
+
+	// Copy files to remote server.
+	remotePaths, err := session.upload(filePaths)
+	if err != nil {
+		return errors.Wrap(err, "error while copying files")
+	}
+
+	if err := session.verifyChecksums(filePaths, remotePaths); err != nil {
+		return errors.Wrap(err, "failed to verify copied files")
+	}
+
+	// Sign files on remote server.
+	remoteSignaturePaths, err := session.sign(ctx, remotePaths)
+	if err != nil {
+		return errors.Wrap(err, "error while signing files")
+	}
+
+	// Fetch signatures from remote server.
+	var signaturePaths []string
+	for _, filePath := range filePaths {
+		signaturePaths = append(signaturePaths, filePath+".sig")
+	}
+	if err := session.download(remoteSignaturePaths, signaturePaths); err != nil {
+		return errors.Wrap(err, "error while copying remote files")
+	}
+
+	return nil
+}
+
+// signingSession holds a single SSH connection to the signing server for a whole signing run.
+// Files are signed in a workspace directory unique to the run, removed with its content by Close.
+type signingSession struct {
+	client      *ssh.Client
+	jumpClient  *ssh.Client
+	sftp        *sftp.Client
+	parallelism int
+
+	signerScript string
+	workspace    string
+
+	mut            sync.Mutex
+	remoteFiles    []string
+	remoteSigFiles []string
+}
+
+// openSigningSession connects to the signing server, through the jump host if one is configured.
+func openSigningSession(cfg *MatterbuildConfig) (*signingSession, error) {
+	clientConfig, err := getSSHClientConfig(cfg.PluginSigningSSHUser, cfg.PluginSigningSSHKeyPath, cfg.PluginSigningSSHPublicCertPath, cfg.PluginSigningSSHHostPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup client config")
+	}
+
+	port := cfg.PluginSigningSSHPort
+	if port == 0 {
+		port = defaultPluginSigningSSHPort
+	}
+	addr := net.JoinHostPort(cfg.PluginSigningSSHHost, strconv.Itoa(port))
+
+	session := &signingSession{
+		parallelism:  cfg.PluginSigningSSHParallelism,
+		signerScript: remoteSignerScript,
+	}
+	if session.parallelism <= 0 {
+		session.parallelism = defaultPluginSigningSSHParallelism
+	}
+
+	if cfg.PluginSigningSSHJumpHost == "" {
+		session.client, err = ssh.Dial("tcp", addr, clientConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to %s", addr)
+		}
+	} else {
+		jumpConfig, err := getSSHClientConfig(cfg.PluginSigningSSHUser, cfg.PluginSigningSSHKeyPath, cfg.PluginSigningSSHPublicCertPath, cfg.PluginSigningSSHJumpHostPublicKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to setup jump host client config")
+		}
+
+		jumpAddr := cfg.PluginSigningSSHJumpHost
+		if _, _, err = net.SplitHostPort(jumpAddr); err != nil {
+			jumpAddr = net.JoinHostPort(jumpAddr, strconv.Itoa(defaultPluginSigningSSHPort))
+		}
+
+		session.jumpClient, err = ssh.Dial("tcp", jumpAddr, jumpConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to jump host %s", jumpAddr)
+		}
+
+		session.client, err = dialThrough(session.jumpClient, addr, clientConfig)
+		if err != nil {
+			session.jumpClient.Close()
+			return nil, errors.Wrapf(err, "failed to connect to %s through jump host %s", addr, jumpAddr)
+		}
+	}
+
+	session.sftp, err = sftp.NewClient(session.client)
+	if err != nil {
+		session.closeConnections()
+		return nil, errors.Wrap(err, "failed to setup sftp client")
+	}
+
+	return session, nil
+}
+
+// dialThrough opens an SSH connection to addr tunneled through the jump client.
+func dialThrough(jumpClient *ssh.Client, addr string, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := jumpClient.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// createWorkspace creates the remote directory of the run in parentDir. The signer user writes the
+// signatures into it, but cannot list it.
+func (s *signingSession) createWorkspace(parentDir string) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "failed to generate workspace name")
+	}
+
+	workspace := path.Join(parentDir, "matterbuild-"+hex.EncodeToString(suffix))
+	if err := s.sftp.Mkdir(workspace); err != nil {
+		return errors.Wrapf(err, "failed to create %s", workspace)
+	}
+	s.workspace = workspace
+
+	if err := s.sftp.Chmod(workspace, 0733); err != nil {
+		return errors.Wrapf(err, "failed to change mode of %s", workspace)
+	}
+
+	LogInfo("Created remote workspace %s", workspace)
+	return nil
+}
+
+// upload copies files into the workspace and returns their remote paths.
+func (s *signingSession) upload(filePaths []string) ([]string, error) {
+	LogInfo("Copying files to the signing server")
+	var result []string
+
+	for _, filePath := range filePaths {
+		serverPath := path.Join(s.workspace, filepath.Base(filePath))
+		LogInfo("copying %s -> %s", filePath, serverPath)
+
+		if err := s.uploadFile(filePath, serverPath); err != nil {
+			return nil, err
+		}
+
+		result = append(result, serverPath)
+	}
+
+	LogInfo("Done copying")
+	return result, nil
+}
+
+func (s *signingSession) uploadFile(filePath, serverPath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %s,", filePath)
+	}
+	defer f.Close()
+
+	dstFile, err := s.sftp.Create(serverPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create remote file %s,", serverPath)
+	}
+	defer dstFile.Close()
+
+	s.mut.Lock()
+	s.remoteFiles = append(s.remoteFiles, serverPath)
+	s.mut.Unlock()
+
+	if _, err := dstFile.ReadFrom(f); err != nil {
+		return errors.Wrap(err, "failed to read from file")
+	}
+
+	return nil
+}
+
+// sign runs the signer script for the remote files, at most parallelism at a time.
+// Returns signature filepaths.
+func (s *signingSession) sign(ctx context.Context, remoteFilePaths []string) ([]string, error) {
+	LogInfo("Starting to sign %s", remoteFilePaths)
+
+	result := make([]string, len(remoteFilePaths))
+	errs := make([]error, len(remoteFilePaths))
+	sem := make(chan struct{}, s.parallelism)
+	var wg sync.WaitGroup
+
+	for i, remoteFilePath := range remoteFilePaths {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, remoteFilePath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result[i], errs[i] = s.signFile(remoteFilePath)
+		}(i, remoteFilePath)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to sign %s", remoteFilePaths[i])
+		}
+	}
+
+	LogInfo("Done signing")
+	return result, nil
+}
+
+func (s *signingSession) signFile(remoteFilePath string) (string, error) {
+	LogInfo("Signing " + remoteFilePath)
+
+	signaturePath := remoteFilePath + ".sig"
+	s.mut.Lock()
+	s.remoteSigFiles = append(s.remoteSigFiles, signaturePath)
+	s.mut.Unlock()
+
+	if _, err := s.run(fmt.Sprintf("sudo -u signer %s %s %s", s.signerScript, shellQuote(remoteFilePath), shellQuote(s.workspace))); err != nil {
+		return "", errors.Wrap(err, "failed to run signer script")
+	}
+
+	return signaturePath, nil
+}
+
+// run runs a command in a new session of the shared connection and returns its output.
+func (s *signingSession) run(cmd string) (string, error) {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open ssh session")
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	err = session.Run(cmd)
+	LogInfo(stdout.String())
+	LogInfo(stderr.String())
+
+	return stdout.String(), err
+}
+
+// verifyChecksums compares the SHA-256 checksums of the local files with the remote copies.
+func (s *signingSession) verifyChecksums(filePaths, remotePaths []string) error {
+	out, err := s.run("sha256sum " + shellQuoteAll(remotePaths))
+	if err != nil {
+		return errors.Wrap(err, "failed to compute remote checksums")
+	}
+
+	remoteChecksums := make(map[string]string, len(remotePaths))
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return errors.Errorf("unexpected sha256sum output %q", line)
+		}
+		remoteChecksums[fields[1]] = fields[0]
+	}
+
+	for i, filePath := range filePaths {
+		checksum, err := sha256File(filePath)
+		if err != nil {
+			return err
+		}
+
+		if remoteChecksums[remotePaths[i]] != checksum {
+			return errors.Errorf("checksum mismatch for %s: expected %s, got %q", remotePaths[i], checksum, remoteChecksums[remotePaths[i]])
+		}
+	}
+
+	LogInfo("Verified checksums of %d copied files", len(filePaths))
+	return nil
+}
+
+// download copies remoteFiles to the matching localFiles.
+func (s *signingSession) download(remoteFiles, localFiles []string) error {
+	LogInfo("Copying files from remote server")
+
+	for i, remoteFile := range remoteFiles {
+		LogInfo("copying %s -> %s", remoteFile, localFiles[i])
+		if err := s.downloadFile(remoteFile, localFiles[i]); err != nil {
+			return err
+		}
+	}
+
+	LogInfo("Done copying files from remote server")
+	return nil
+}
+
+func (s *signingSession) downloadFile(remoteFile, destination string) error {
+	srcFile, err := s.sftp.Open(remoteFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open remote file %s,", remoteFile)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(destination)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file %s,", destination)
+	}
+	defer dstFile.Close()
+
+	if _, err := srcFile.WriteTo(dstFile); err != nil {
+		return errors.Wrap(err, "error while reading from remote buffer")
+	}
+
+	return dstFile.Close()
+}
+
+// Close removes the uploaded files and the signatures from the server, and closes the connection.
+func (s *signingSession) Close() error {
+	LogInfo("Removing files from remote server")
+
+	var errs []string
+	for _, remoteFile := range s.remoteFiles {
+		if err := s.sftp.Remove(remoteFile); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Sprintf("failed to remove %s: %s", remoteFile, err))
+		}
+	}
+
+	// Signatures belong to the signer user
+	if len(s.remoteSigFiles) > 0 {
+		if _, err := s.run("sudo -u signer rm -f " + shellQuoteAll(s.remoteSigFiles)); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove signatures: %s", err))
+		}
+	}
+
+	if s.workspace != "" {
+		if err := s.sftp.RemoveDirectory(s.workspace); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove %s: %s", s.workspace, err))
+		}
+	}
+
+	s.closeConnections()
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	LogInfo("Done removing files from remote server")
+	return nil
+}
+
+func (s *signingSession) closeConnections() {
+	if s.sftp != nil {
+		s.sftp.Close()
+	}
+	s.client.Close()
+	if s.jumpClient != nil {
+		s.jumpClient.Close()
+	}
+}
+
+// getSSHClientConfig Loads a private and public key from "path" and returns a SSH ClientConfig to authenticate with the server.
+func getSSHClientConfig(username, path, certPath, hostPublicKey string) (*ssh.ClientConfig, error) {
+	privateKey, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read key path")
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	// Load the certificate if present
+	if certPath != "" {
+		var cert []byte
+		cert, err = os.ReadFile(certPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read cert path")
+		}
+
+		var pk ssh.PublicKey
+		pk, _, _, _, err = ssh.ParseAuthorizedKey(cert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse authorized key")
+		}
+
+		signer, err = ssh.NewCertSigner(pk.(*ssh.Certificate), signer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get cert signer")
+		}
+	}
+
+	if hostPublicKey == "" {
+		return nil, errors.New("missing host public key")
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostPublicKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parse host public key")
+	}
+
+	return &ssh.ClientConfig{
+		User: username,
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         30 * time.Second,
+	}, nil
+}
+
+// shellQuote quotes a path for the remote shell, the remote paths being made of the repository,
+// tag and workspace names.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellQuoteAll quotes each of the paths and joins them with spaces.
+func shellQuoteAll(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = shellQuote(path)
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,432 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer is an in-process SSH server serving SFTP on the local filesystem, running exec
+// requests through a callback, and forwarding direct-tcpip channels to act as a jump host.
+type testSSHServer struct {
+	addr      string
+	publicKey string
+
+	mut         sync.Mutex
+	connections int
+	commands    []string
+}
+
+func startTestSSHServer(t *testing.T, clientKey ssh.PublicKey, exec func(cmd string) (string, error)) *testSSHServer {
+	t.Helper()
+
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivateKey)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testSSHServer{
+		addr:      listener.Addr().String(),
+		publicKey: string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey())),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config, exec)
+		}
+	}()
+
+	return server
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig, exec func(cmd string) (string, error)) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	s.mut.Lock()
+	s.connections++
+	s.mut.Unlock()
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				continue
+			}
+			go s.serveSession(channel, requests, exec)
+		case "direct-tcpip":
+			var target struct {
+				Host       string
+				Port       uint32
+				OriginHost string
+				OriginPort uint32
+			}
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+			if err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				targetConn.Close()
+				continue
+			}
+			go ssh.DiscardRequests(requests)
+			go func() {
+				io.Copy(targetConn, channel)
+				targetConn.Close()
+			}()
+			go func() {
+				io.Copy(channel, targetConn)
+				channel.Close()
+			}()
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *testSSHServer) serveSession(channel ssh.Channel, requests <-chan *ssh.Request, exec func(cmd string) (string, error)) {
+	defer channel.Close()
+
+	for req := range requests {
+		switch req.Type {
+		case "subsystem":
+			req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			server.Serve()
+			return
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				return
+			}
+			req.Reply(true, nil)
+
+			s.mut.Lock()
+			s.commands = append(s.commands, payload.Command)
+			s.mut.Unlock()
+
+			status := make([]byte, 4)
+			stdout, err := exec(payload.Command)
+			io.WriteString(channel, stdout)
+			if err != nil {
+				io.WriteString(channel.Stderr(), err.Error())
+				binary.BigEndian.PutUint32(status, 1)
+			}
+			channel.SendRequest("exit-status", false, status)
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// writeTestSSHKey generates a client key and returns its path along with its public key.
+func writeTestSSHKey(t *testing.T, dir string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(dir, "id_ecdsa")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+
+	return keyPath, signer.PublicKey()
+}
+
+// fakeSignerScript emulates the signer script, sha256sum and the removal of signatures on the signing server.
+func fakeSignerScript(signer Signer) func(cmd string) (string, error) {
+	return func(cmd string) (string, error) {
+		args := strings.Fields(strings.TrimPrefix(cmd, "sudo -u signer "))
+		// The test paths have no spaces nor quotes, their quoting only wraps them
+		for i, arg := range args {
+			args[i] = strings.Trim(arg, "'")
+		}
+		switch {
+		case len(args) == 3 && args[0] == remoteSignerScript:
+			output := filepath.Join(args[2], "signer-"+filepath.Base(args[1]))
+			data, err := os.ReadFile(args[1])
+			if err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(output, data, 0600); err != nil {
+				return "", err
+			}
+			defer os.Remove(output)
+			// The local signer writes <file>.sig next to the file
+			if err := signer.Sign(context.Background(), []string{output}); err != nil {
+				return "", err
+			}
+			return "", os.Rename(output+".sig", args[1]+".sig")
+		case len(args) > 1 && args[0] == "sha256sum":
+			var out strings.Builder
+			for _, arg := range args[1:] {
+				checksum, err := sha256File(arg)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(&out, "%s  %s\n", checksum, arg)
+			}
+			return out.String(), nil
+		case len(args) > 2 && args[0] == "rm" && args[1] == "-f":
+			for _, arg := range args[2:] {
+				if err := os.Remove(arg); err != nil && !os.IsNotExist(err) {
+					return "", err
+				}
+			}
+			return "", nil
+		default:
+			return "", errors.Errorf("unexpected command %q", cmd)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	require.Equal(t, `'/tmp/matterbuild-1/plugin.tar.gz'`, shellQuote("/tmp/matterbuild-1/plugin.tar.gz"))
+	require.Equal(t, `'/tmp/a b; rm -rf ~'`, shellQuote("/tmp/a b; rm -rf ~"))
+	require.Equal(t, `'/tmp/it'\''s $(id)'`, shellQuote("/tmp/it's $(id)"))
+	require.Equal(t, `'a' 'b c'`, shellQuoteAll([]string{"a", "b c"}))
+}
+
+func TestSigningSession(t *testing.T) {
+	dir := t.TempDir()
+	sshKeyPath, sshPublicKey := writeTestSSHKey(t, dir)
+	privateKeyPath, publicKeyPath, keyID := writeTestSigningKey(t, dir)
+	pgpSigner, err := newLocalSigner(privateKeyPath, "")
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, exec func(cmd string) (string, error)) (*testSSHServer, *MatterbuildConfig) {
+		if exec == nil {
+			exec = fakeSignerScript(pgpSigner)
+		}
+
+		server := startTestSSHServer(t, sshPublicKey, exec)
+		host, port, err := net.SplitHostPort(server.addr)
+		require.NoError(t, err)
+		portNumber, err := strconv.Atoi(port)
+		require.NoError(t, err)
+
+		cfg := &MatterbuildConfig{
+			PluginSigningSSHUser:          "matterbuild",
+			PluginSigningSSHKeyPath:       sshKeyPath,
+			PluginSigningSSHHost:          host,
+			PluginSigningSSHPort:          portNumber,
+			PluginSigningSSHHostPublicKey: server.publicKey,
+			PluginSigningSSHParallelism:   2,
+		}
+
+		return server, cfg
+	}
+
+	// open opens a session with its workspace in a temporary directory standing for /tmp.
+	open := func(t *testing.T, cfg *MatterbuildConfig) (*signingSession, string) {
+		session, err := openSigningSession(cfg)
+		require.NoError(t, err)
+
+		parentDir := t.TempDir()
+		require.NoError(t, session.createWorkspace(parentDir))
+		require.DirExists(t, session.workspace)
+		require.Equal(t, parentDir, filepath.Dir(session.workspace))
+
+		return session, parentDir
+	}
+
+	writePlugins := func(t *testing.T, n int) []string {
+		pluginDir := t.TempDir()
+		var pluginPaths []string
+		for i := 0; i < n; i++ {
+			pluginPath := filepath.Join(pluginDir, "plugin-"+strconv.Itoa(i)+".tar.gz")
+			require.NoError(t, os.WriteFile(pluginPath, []byte(pluginPath), 0600))
+			pluginPaths = append(pluginPaths, pluginPath)
+		}
+
+		return pluginPaths
+	}
+
+	requireEmpty := func(t *testing.T, dir string) {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	}
+
+	t.Run("sign over a single connection", func(t *testing.T) {
+		server, cfg := setup(t, nil)
+		session, parentDir := open(t, cfg)
+		pluginPaths := writePlugins(t, 5)
+
+		remotePaths, err := session.upload(pluginPaths)
+		require.NoError(t, err)
+		require.NoError(t, session.verifyChecksums(pluginPaths, remotePaths))
+		remoteSignaturePaths, err := session.sign(context.Background(), remotePaths)
+		require.NoError(t, err)
+
+		var signaturePaths []string
+		for _, pluginPath := range pluginPaths {
+			signaturePaths = append(signaturePaths, pluginPath+".sig")
+		}
+		require.NoError(t, session.download(remoteSignaturePaths, signaturePaths))
+		require.NoError(t, session.Close())
+
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{PluginSigningPublicKeyPath: publicKeyPath})
+		require.NoError(t, err)
+		signers, err := verifySignatures(keyring, pluginPaths)
+		require.NoError(t, err)
+		for _, pluginPath := range pluginPaths {
+			require.Equal(t, keyID, signers[pluginPath])
+		}
+
+		require.Equal(t, 1, server.connections)
+		require.Len(t, server.commands, len(pluginPaths)+2)
+		requireEmpty(t, parentDir)
+	})
+
+	t.Run("concurrent sessions do not collide", func(t *testing.T) {
+		_, cfg := setup(t, nil)
+		session1, err := openSigningSession(cfg)
+		require.NoError(t, err)
+		session2, err := openSigningSession(cfg)
+		require.NoError(t, err)
+
+		parentDir := t.TempDir()
+		require.NoError(t, session1.createWorkspace(parentDir))
+		require.NoError(t, session2.createWorkspace(parentDir))
+		require.NotEqual(t, session1.workspace, session2.workspace)
+
+		pluginPaths := writePlugins(t, 1)
+		remotePaths1, err := session1.upload(pluginPaths)
+		require.NoError(t, err)
+		remotePaths2, err := session2.upload(pluginPaths)
+		require.NoError(t, err)
+		require.NotEqual(t, remotePaths1, remotePaths2)
+
+		require.NoError(t, session1.Close())
+		require.FileExists(t, remotePaths2[0])
+		require.NoError(t, session2.Close())
+		requireEmpty(t, parentDir)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		_, cfg := setup(t, nil)
+		session, parentDir := open(t, cfg)
+		pluginPaths := writePlugins(t, 2)
+
+		remotePaths, err := session.upload(pluginPaths)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(remotePaths[1], []byte("corrupted"), 0600))
+
+		err = session.verifyChecksums(pluginPaths, remotePaths)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "checksum mismatch for "+remotePaths[1])
+
+		require.NoError(t, session.Close())
+		requireEmpty(t, parentDir)
+	})
+
+	t.Run("clean up after a signing failure", func(t *testing.T) {
+		fake := fakeSignerScript(pgpSigner)
+		server, cfg := setup(t, func(cmd string) (string, error) {
+			if strings.Contains(cmd, remoteSignerScript) && strings.Contains(cmd, "plugin-1") {
+				return "", errors.New("signing failed")
+			}
+			return fake(cmd)
+		})
+		session, parentDir := open(t, cfg)
+
+		remotePaths, err := session.upload(writePlugins(t, 3))
+		require.NoError(t, err)
+		_, err = session.sign(context.Background(), remotePaths)
+		require.Error(t, err)
+		require.NoError(t, session.Close())
+
+		require.Equal(t, 1, server.connections)
+		requireEmpty(t, parentDir)
+	})
+
+	t.Run("sign through a jump host", func(t *testing.T) {
+		server, cfg := setup(t, nil)
+		jumpHost := startTestSSHServer(t, sshPublicKey, func(cmd string) (string, error) {
+			return "", errors.New("unexpected command on the jump host")
+		})
+		cfg.PluginSigningSSHJumpHost = jumpHost.addr
+		cfg.PluginSigningSSHJumpHostPublicKey = jumpHost.publicKey
+
+		session, parentDir := open(t, cfg)
+		remotePaths, err := session.upload(writePlugins(t, 1))
+		require.NoError(t, err)
+		_, err = session.sign(context.Background(), remotePaths)
+		require.NoError(t, err)
+		require.NoError(t, session.Close())
+
+		require.Equal(t, 1, jumpHost.connections)
+		require.Empty(t, jumpHost.commands)
+		require.Equal(t, 1, server.connections)
+		requireEmpty(t, parentDir)
+	})
+
+	t.Run("wrong host key", func(t *testing.T) {
+		_, cfg := setup(t, nil)
+		cfg.PluginSigningSSHHostPublicKey = string(ssh.MarshalAuthorizedKey(sshPublicKey))
+
+		_, err := openSigningSession(cfg)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestNewSigner(t *testing.T) {
+	t.Run("defaults to ssh", func(t *testing.T) {
+		signer, err := newSigner(&MatterbuildConfig{})
+		require.NoError(t, err)
+		require.IsType(t, &sshSigner{}, signer)
+	})
+
+	t.Run("ssh", func(t *testing.T) {
+		signer, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "ssh"})
+		require.NoError(t, err)
+		require.IsType(t, &sshSigner{}, signer)
+	})
+
+	t.Run("gpg", func(t *testing.T) {
+		signer, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "gpg", PluginSigningGPGKeyID: "ABCDEF"})
+		require.NoError(t, err)
+		require.IsType(t, &gpgSigner{}, signer)
+	})
+
+	t.Run("gpg without key id", func(t *testing.T) {
+		_, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "gpg"})
+		require.Error(t, err)
+	})
+
+	t.Run("local", func(t *testing.T) {
+		privateKeyPath, _, _ := writeTestSigningKey(t, t.TempDir())
+		signer, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "local", PluginSigningPrivateKeyPath: privateKeyPath})
+		require.NoError(t, err)
+		require.IsType(t, &localSigner{}, signer)
+	})
+
+	t.Run("local without private key", func(t *testing.T) {
+		_, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "local"})
+		require.Error(t, err)
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := newSigner(&MatterbuildConfig{PluginSigningBackend: "kms"})
+		require.EqualError(t, err, `unknown plugin signing backend "kms"`)
+	})
+}
+
+func TestGPGSigner(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	dir := t.TempDir()
+	homeDir := filepath.Join(dir, "gnupg")
+	require.NoError(t, os.Mkdir(homeDir, 0700))
+
+	privateKeyPath, publicKeyPath, keyID := writeTestSigningKey(t, dir)
+
+	privateKey, err := os.Open(privateKeyPath)
+	require.NoError(t, err)
+	defer privateKey.Close()
+
+	cmd := exec.Command("gpg", "--batch", "--homedir", homeDir, "--import")
+	cmd.Stdin = privateKey
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	pluginPath := filepath.Join(dir, "plugin.tar.gz")
+	require.NoError(t, os.WriteFile(pluginPath, []byte("plugin"), 0600))
+
+	cfg := &MatterbuildConfig{
+		PluginSigningBackend:       "gpg",
+		PluginSigningPublicKeyPath: publicKeyPath,
+		PluginSigningGPGHomeDir:    homeDir,
+		PluginSigningGPGKeyID:      keyID,
+	}
+	signer, err := newSigner(cfg)
+	require.NoError(t, err)
+
+	_, err = signPlugins(context.Background(), cfg, signer, []string{pluginPath})
+	require.NoError(t, err)
+	require.FileExists(t, pluginPath+".sig")
+
+	t.Run("tampered file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(pluginPath, []byte("tampered"), 0600))
+
+		keyring, err := loadPluginPublicKeyring(cfg)
+		require.NoError(t, err)
+		_, err = verifySignatures(keyring, []string{pluginPath})
+		require.Error(t, err)
+	})
+}
+
+func TestLocalSigner(t *testing.T) {
+	dir := t.TempDir()
+	privateKeyPath, publicKeyPath, _ := writeTestSigningKey(t, dir)
+
+	t.Run("public key only", func(t *testing.T) {
+		_, err := newLocalSigner(publicKeyPath, "")
+		require.Error(t, err)
+	})
+
+	cfg := &MatterbuildConfig{
+		PluginSigningBackend:        "local",
+		PluginSigningPublicKeyPath:  publicKeyPath,
+		PluginSigningPrivateKeyPath: privateKeyPath,
+	}
+	signer, err := newSigner(cfg)
+	require.NoError(t, err)
+
+	pluginPaths := []string{filepath.Join(dir, "plugin.tar.gz"), filepath.Join(dir, "plugin-linux-amd64.tar.gz")}
+	for _, pluginPath := range pluginPaths {
+		require.NoError(t, os.WriteFile(pluginPath, []byte(pluginPath), 0600))
+	}
+
+	keyIDs, err := signPlugins(context.Background(), cfg, signer, pluginPaths)
+	require.NoError(t, err)
+	require.Len(t, keyIDs, len(pluginPaths))
+
+	t.Run("production key", func(t *testing.T) {
+		keyring, err := loadPluginPublicKeyring(&MatterbuildConfig{})
+		require.NoError(t, err)
+		_, err = verifySignatures(keyring, pluginPaths)
+		require.Error(t, err)
+	})
+}
+
+// writeTestSigningKey generates a signing key and writes its armored private and public
+// parts into dir. It returns both paths and the key ID.
+func writeTestSigningKey(t *testing.T, dir string) (privateKeyPath, publicKeyPath, keyID string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Matterbuild Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	privateKeyPath = filepath.Join(dir, "private.asc")
+	f, err := os.Create(privateKeyPath)
+	require.NoError(t, err)
+	w, err := armor.Encode(f, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	publicKeyPath = filepath.Join(dir, "public.asc")
+	f, err = os.Create(publicKeyPath)
+	require.NoError(t, err)
+	w, err = armor.Encode(f, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	return privateKeyPath, publicKeyPath, entity.PrimaryKey.KeyIdString()
+}